@@ -1,20 +1,48 @@
 package cmd
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/cwel/kmux/internal/config"
+	"github.com/cwel/kmux/internal/kitty"
+	"github.com/cwel/kmux/internal/manager"
+	"github.com/cwel/kmux/internal/project"
 	"github.com/cwel/kmux/internal/state"
 	"github.com/cwel/kmux/internal/store"
+	"github.com/sahilm/fuzzy"
 	"github.com/spf13/cobra"
 )
 
 var (
-	attachLayout string
-	attachCWD    string
-	attachHost   string
+	attachLayout     string
+	attachLayoutFile string
+	attachCWD        string
+	attachCWDFromWin bool
+	attachHost       string
+	attachDetached   bool
+	attachPick       bool
+	attachReadOnly   bool
+	attachTab        int
+	attachNoSave     bool
+	attachIfExists   string
+	attachIgnoreHost bool
+	attachGrid       int
+	attachColumns    int
+	attachWait       bool
+	attachWaitPoll   time.Duration
+	attachWaitMax    time.Duration
+	attachPrintCmd   bool
+	attachReplace    bool
+	attachForce      bool
 )
 
 var attachCmd = &cobra.Command{
@@ -28,32 +56,333 @@ Examples:
   kmux a myproject          # session named "myproject"
   kmux a ~/src/foo          # session "foo" starting in ~/src/foo
   kmux a ~/src/foo bar      # session "bar" starting in ~/src/foo
-  kmux a myproject --host devbox  # remote session on devbox`,
+  kmux a -                  # most recently saved session (like cd -)
+  kmux a myproject --host devbox  # remote session on devbox
+  kmux a devbox:myproject         # same, host:name shorthand
+  kmux a myproject@devbox         # same, as shell completion offers it
+  kmux a myproject --detached     # start in background without windows
+  kmux a --pick                   # fuzzy-pick a session from all hosts
+  kmux a pairing --read-only      # watch a running session without typing into it
+  kmux a --layout-file ./dev.yaml # one-off layout without installing it
+  kmux a myproject --tab 2        # reattach and focus the third tab
+  kmux a myproject --if-exists    # only attach if the session already exists
+  kmux a scratch --cwd-from-window # start in the focused pane's directory
+  kmux a scratch --grid 4         # four shells in a grid
+  kmux a scratch --columns 3      # three shells side by side
+  kmux a build --layout ci --detached --wait  # run a job, block until it exits
+  kmux a myproject --replace      # throw away the live layout, restore the save file
+
+Save files record the host they were saved for, and a local attach skips
+save files from another host. With a config directory synced between
+machines, use --ignore-host to restore such a layout on this machine
+anyway; its panes start fresh zmx sessions here.
+
+With --wait, attach blocks until every pane of the session has exited
+(checked with zmx list every --wait-interval), which lets scripts use a
+session as a job runner. zmx doesn't report exit statuses, so the command
+exits 0 once the session is gone, or fails after --wait-timeout. A session
+whose panes never show up in zmx list within 30s is assumed to have exited
+already (e.g. a job that finished before the first check). A pane
+only exits when its shell does, so commands meant to finish must end the
+shell too: use "exec make test" (or "make test; exit") in the layout.
+
+--replace kills the session's windows and zmx sessions (their shells and
+programs exit) and recreates the session from its save file, like kill and
+attach without losing the save file. It asks for confirmation unless --force
+is given.
+
+--print-cmd (or KMUX_DEBUG=1 for any command) prints each kitty @ command,
+including the kitten ssh ... zmx attach line of every launched pane, to
+stderr as it runs, quoted so it can be pasted into a shell.`,
 	Args:              cobra.RangeArgs(0, 2),
 	ValidArgsFunction: completeSessionNames,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		name, cwd, err := resolveAttachArgs(args, attachCWD)
+		if attachIfExists != "" && attachIfExists != "skip" && attachIfExists != "error" {
+			return fmt.Errorf("invalid --if-exists value %q (use skip or error)", attachIfExists)
+		}
+		if attachLayout != "" && attachLayoutFile != "" {
+			return fmt.Errorf("--layout and --layout-file are mutually exclusive")
+		}
+		if attachCWD != "" && attachCWDFromWin {
+			return fmt.Errorf("--cwd and --cwd-from-window are mutually exclusive")
+		}
+		layoutFlags := 0
+		for _, set := range []bool{attachLayout != "", attachLayoutFile != "", attachGrid != 0, attachColumns != 0} {
+			if set {
+				layoutFlags++
+			}
+		}
+		if layoutFlags > 1 {
+			return fmt.Errorf("--layout, --layout-file, --grid and --columns are mutually exclusive")
+		}
+		if attachReplace && (layoutFlags > 0 || attachReadOnly) {
+			return fmt.Errorf("--replace restores the save file and can't be combined with --layout, --layout-file, --grid, --columns or --read-only")
+		}
+		if attachWait && attachWaitPoll <= 0 {
+			return fmt.Errorf("--wait-interval must be positive")
+		}
+		if attachGrid < 0 || attachColumns < 0 {
+			return fmt.Errorf("--grid and --columns need a positive pane count")
+		}
+		var layoutFile *config.Layout
+		switch {
+		case attachLayoutFile != "":
+			var err error
+			if layoutFile, err = readLayoutFile(attachLayoutFile); err != nil {
+				return err
+			}
+		case attachGrid > 0:
+			layoutFile = manager.PaneLayout(attachGrid, "grid")
+		case attachColumns > 0:
+			layoutFile = manager.PaneLayout(attachColumns, "horizontal")
+		}
+
+		s, err := newState()
 		if err != nil {
 			return err
 		}
+		if attachPrintCmd {
+			s.KittyClient().SetTrace(os.Stderr)
+		}
 
-		if err := store.ValidateSessionName(name); err != nil {
+		// "-" resolves to the most recent session, like cd -
+		host := attachHost
+		if attachPick {
+			picked, err := pickSession(s)
+			if err != nil {
+				return err
+			}
+			args = []string{picked.Name}
+			host = picked.Host
+		} else if len(args) == 1 && args[0] == "-" {
+			recent, err := mostRecentSession(s)
+			if err != nil {
+				return err
+			}
+			args = []string{recent.Name}
+			if host == "" {
+				host = recent.Host
+			}
+		}
+
+		nameStrategy := ""
+		if cfg := s.Config(); cfg != nil {
+			nameStrategy = cfg.Sessions.NameStrategy
+		}
+		name, cwd, argHost, err := resolveAttachArgs(args, attachCWD, nameStrategy, s.ConfiguredHosts())
+		if err != nil {
 			return err
 		}
+		if argHost != "" {
+			if host != "" && host != argHost {
+				return fmt.Errorf("host %s in %s conflicts with --host %s", argHost, args[len(args)-1], host)
+			}
+			host = argHost
+		}
+		if attachCWDFromWin {
+			if cwd, err = activeWindowCWD(s); err != nil {
+				return err
+			}
+		}
 
-		s := state.New()
+		if err := store.ValidateSessionName(name); err != nil {
+			return err
+		}
 
 		// Determine which host to use
-		host := attachHost
 		if host == "" {
 			// Auto-detect: find which host(s) have a session with this name
-			host = autoDetectSessionHost(s, name)
+			if host, err = autoDetectSessionHost(s, name); err != nil {
+				return err
+			}
+		}
+
+		// Scripts can refuse to create sessions (e.g. named after a transient cwd)
+		if attachIfExists != "" && !manager.SessionExists(s, name, host) {
+			if attachIfExists == "error" {
+				return fmt.Errorf("session not found: %s", name)
+			}
+			return nil
 		}
 
-		return attachSessionWithHost(s, name, cwd, attachLayout, host)
+		if attachReplace && !attachForce {
+			if !stdinIsTerminal() {
+				return fmt.Errorf("--replace kills running programs; pass --force to confirm")
+			}
+			fmt.Fprintf(os.Stderr, "Kill session %s and rebuild it from its save file? [y/N] ", name)
+			answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			answer = strings.ToLower(strings.TrimSpace(answer))
+			if answer != "y" && answer != "yes" {
+				fmt.Fprintln(os.Stderr, "Aborted")
+				return nil
+			}
+		}
+
+		if err := attachSession(s, manager.AttachOpts{
+			Name:         name,
+			Host:         host,
+			CWD:          cwd,
+			Layout:       attachLayout,
+			LayoutFile:   layoutFile,
+			BeforePinned: true,
+			Detached:     attachDetached,
+			ReadOnly:     attachReadOnly,
+			Tab:          attachTab,
+			NoSave:       attachNoSave,
+			IgnoreHost:   attachIgnoreHost,
+			Replace:      attachReplace,
+		}); err != nil || !attachWait {
+			return err
+		}
+		return manager.WaitSession(s, name, host, attachWaitPoll, attachWaitMax)
 	},
 }
 
+// mostRecentSession returns the local session with the latest save timestamp,
+// skipping the session the current window belongs to.
+func mostRecentSession(s *state.State) (*state.SessionInfo, error) {
+	sessions, err := s.Sessions(true)
+	if err != nil && len(sessions) == 0 {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+
+	current := ""
+	if info, _, _, _ := s.GetCurrentSession(); info != nil {
+		current = info.Name
+	}
+
+	var recent *state.SessionInfo
+	for i := range sessions {
+		sess := &sessions[i]
+		if sess.Name == current {
+			continue
+		}
+		if recent == nil || sess.LastSeen.After(recent.LastSeen) {
+			recent = sess
+		}
+	}
+	if recent == nil {
+		return nil, fmt.Errorf("no previous session to attach to")
+	}
+	return recent, nil
+}
+
+// pickSession prompts for a session across all hosts, narrowing the list
+// by fuzzy matching name@host until a single entry is chosen.
+func pickSession(s *state.State) (*state.SessionInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	sessions, err := s.AllSessions(ctx, true)
+	if len(sessions) == 0 {
+		if err != nil {
+			return nil, fmt.Errorf("list sessions: %w", err)
+		}
+		return nil, fmt.Errorf("no sessions to pick from")
+	}
+	return pickFromSessions(sessions)
+}
+
+// pickFromSessions runs the numbered/fuzzy prompt over the given sessions.
+func pickFromSessions(sessions []state.SessionInfo) (*state.SessionInfo, error) {
+	labels := make([]string, len(sessions))
+	for i, sess := range sessions {
+		labels[i] = sess.Name + "@" + sess.Host
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	candidates := make([]int, len(sessions))
+	for i := range candidates {
+		candidates[i] = i
+	}
+
+	for {
+		for n, idx := range candidates {
+			fmt.Fprintf(os.Stderr, "%3d  %s (%s)\n", n+1, labels[idx], sessions[idx].Status)
+		}
+		fmt.Fprint(os.Stderr, "Pick a number or type to filter: ")
+
+		line, err := reader.ReadString('\n')
+		query := strings.TrimSpace(line)
+		if err != nil && query == "" {
+			return nil, fmt.Errorf("no session picked")
+		}
+
+		if n, convErr := strconv.Atoi(query); convErr == nil && n >= 1 && n <= len(candidates) {
+			return &sessions[candidates[n-1]], nil
+		}
+		if query == "" {
+			if len(candidates) == 1 {
+				return &sessions[candidates[0]], nil
+			}
+			continue
+		}
+
+		// Filter the current candidates, best matches first
+		pool := make([]string, len(candidates))
+		for n, idx := range candidates {
+			pool[n] = labels[idx]
+		}
+		matches := fuzzy.Find(query, pool)
+		if len(matches) == 0 {
+			fmt.Fprintf(os.Stderr, "No sessions match %q\n", query)
+			continue
+		}
+		filtered := make([]int, len(matches))
+		for n, match := range matches {
+			filtered[n] = candidates[match.Index]
+		}
+		if len(filtered) == 1 {
+			return &sessions[filtered[0]], nil
+		}
+		candidates = filtered
+	}
+}
+
+// activeWindowCWD returns the working directory of the focused kitty window.
+// Remote panes report the directory from the REMOTE_CWD user var (set by shell
+// integration on the remote), since kitty only sees the local ssh process.
+func activeWindowCWD(s *state.State) (string, error) {
+	kittyState, err := s.KittyClient().GetState()
+	if err != nil {
+		return "", fmt.Errorf("get kitty state: %w", err)
+	}
+	win := kitty.FindActiveWindow(kittyState)
+	if win == nil {
+		return "", fmt.Errorf("no active kitty window")
+	}
+	if cwd := win.UserVars["REMOTE_CWD"]; cwd != "" {
+		return cwd, nil
+	}
+	if win.CWD == "" {
+		return "", fmt.Errorf("active kitty window %d has no working directory", win.ID)
+	}
+	return win.CWD, nil
+}
+
+// readLayoutFile reads and validates a layout YAML file ("-" reads stdin).
+func readLayoutFile(path string) (*config.Layout, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read layout file: %w", err)
+	}
+
+	layout, err := config.ParseLayout(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse layout %s: %w", path, err)
+	}
+	if err := layout.Validate(); err != nil {
+		return nil, fmt.Errorf("validate layout %s: %w", path, err)
+	}
+	return layout, nil
+}
+
 // isPath returns true if the argument looks like a path (starts with /, ~, or .)
 func isPath(arg string) bool {
 	return strings.HasPrefix(arg, "/") ||
@@ -75,58 +404,101 @@ func expandPath(path string) (string, error) {
 
 // resolveAttachArgs determines session name and cwd from command arguments.
 // Args patterns:
-//   - 0 args: name = cwd basename, cwd = current
-//   - 1 arg (path): name = path basename, cwd = path
+//   - 0 args: name = derived from cwd, cwd = current
+//   - 1 arg (path): name = derived from path, cwd = path
 //   - 1 arg (name): name = arg, cwd = current
 //   - 2 args: name = args[1], cwd = args[0] (path)
-func resolveAttachArgs(args []string, cwdOverride string) (name, cwd string, err error) {
+//
+// Derived names follow nameStrategy ([sessions] name_strategy).
+// A name may be given as host:name when host is "local" or one of hosts;
+// host is returned empty otherwise.
+func resolveAttachArgs(args []string, cwdOverride, nameStrategy string, hosts []string) (name, cwd, host string, err error) {
 	// Start with current directory
 	cwd, err = os.Getwd()
 	if err != nil {
-		return "", "", fmt.Errorf("get cwd: %w", err)
+		return "", "", "", fmt.Errorf("get cwd: %w", err)
 	}
 
 	switch len(args) {
 	case 0:
 		// No args: derive name from cwd
-		name = filepath.Base(cwd)
+		if name, err = project.SessionName(cwd, nameStrategy); err != nil {
+			return "", "", "", err
+		}
 
 	case 1:
 		if isPath(args[0]) {
 			// Single path arg: derive name from path, use path as cwd
 			cwd, err = expandPath(args[0])
 			if err != nil {
-				return "", "", fmt.Errorf("expand path: %w", err)
+				return "", "", "", fmt.Errorf("expand path: %w", err)
+			}
+			if name, err = project.SessionName(cwd, nameStrategy); err != nil {
+				return "", "", "", err
 			}
-			name = filepath.Base(cwd)
 		} else {
 			// Single name arg: use as session name
-			name = args[0]
+			host, name = splitHostName(args[0], hosts)
 		}
 
 	case 2:
 		// Two args: path + name
 		cwd, err = expandPath(args[0])
 		if err != nil {
-			return "", "", fmt.Errorf("expand path: %w", err)
+			return "", "", "", fmt.Errorf("expand path: %w", err)
 		}
-		name = args[1]
+		host, name = splitHostName(args[1], hosts)
 	}
 
 	// Override cwd if flag provided
 	if cwdOverride != "" {
 		cwd, err = expandPath(cwdOverride)
 		if err != nil {
-			return "", "", fmt.Errorf("expand cwd override: %w", err)
+			return "", "", "", fmt.Errorf("expand cwd override: %w", err)
 		}
 	}
 
-	return name, cwd, nil
+	return name, cwd, host, nil
+}
+
+// splitHostName splits a host:name (or name@host) argument when the host is
+// "local" or a configured host. Anything else, including Windows-style paths
+// like C:\src, is returned whole as the name with an empty host.
+func splitHostName(arg string, hosts []string) (host, name string) {
+	if name, host := splitSessionHost(arg, hosts); host != "" {
+		return host, name
+	}
+	prefix, rest, ok := strings.Cut(arg, ":")
+	if !ok || prefix == "" || rest == "" || strings.ContainsAny(rest[:1], `/\`) {
+		return "", arg
+	}
+	if prefix == "local" || slices.Contains(hosts, prefix) {
+		return prefix, rest
+	}
+	return "", arg
 }
 
 func init() {
 	attachCmd.Flags().StringVarP(&attachLayout, "layout", "l", "", "create session from layout template")
+	attachCmd.Flags().StringVar(&attachLayoutFile, "layout-file", "", "create session from a layout YAML file (- for stdin)")
 	attachCmd.Flags().StringVarP(&attachCWD, "cwd", "C", "", "working directory for panes (overrides path)")
+	attachCmd.Flags().BoolVar(&attachCWDFromWin, "cwd-from-window", false, "use the focused kitty window's directory for panes")
 	attachCmd.Flags().StringVarP(&attachHost, "host", "H", "", "remote host (SSH alias from config)")
+	attachCmd.Flags().BoolVarP(&attachDetached, "detached", "d", false, "start zmx sessions without opening kitty windows")
+	attachCmd.Flags().BoolVar(&attachReadOnly, "read-only", false, "reattach to a running session without sending input")
+	attachCmd.Flags().IntVarP(&attachTab, "tab", "t", 0, "tab index to focus after attaching (0 = first)")
+	attachCmd.Flags().StringVar(&attachIfExists, "if-exists", "", "only attach to an existing session; otherwise do nothing (skip) or fail (error)")
+	attachCmd.Flags().Lookup("if-exists").NoOptDefVal = "skip"
+	attachCmd.Flags().BoolVar(&attachPrintCmd, "print-cmd", false, "print kitty launch commands to stderr as they run")
+	attachCmd.Flags().BoolVar(&attachReplace, "replace", false, "kill the running session and rebuild it from its save file")
+	attachCmd.Flags().BoolVarP(&attachForce, "force", "f", false, "don't ask before --replace kills the session")
+	attachCmd.Flags().BoolVarP(&attachWait, "wait", "w", false, "block until all of the session's panes have exited")
+	attachCmd.Flags().DurationVar(&attachWaitPoll, "wait-interval", 2*time.Second, "how often --wait checks the session")
+	attachCmd.Flags().DurationVar(&attachWaitMax, "wait-timeout", 0, "give up waiting after this long (0 = never)")
+	attachCmd.Flags().IntVar(&attachGrid, "grid", 0, "create the session with N shell panes in a grid")
+	attachCmd.Flags().IntVar(&attachColumns, "columns", 0, "create the session with N shell panes side by side")
+	attachCmd.Flags().BoolVar(&attachIgnoreHost, "ignore-host", false, "restore a local save file even if it was saved for another host")
+	attachCmd.Flags().BoolVar(&attachNoSave, "no-save", false, "never save this session's layout on detach")
+	attachCmd.Flags().BoolVarP(&attachPick, "pick", "p", false, "interactively pick a session from all hosts")
 	rootCmd.AddCommand(attachCmd)
 }
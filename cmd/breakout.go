@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cwel/kmux/internal/kitty"
+	"github.com/cwel/kmux/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+var breakoutCmd = &cobra.Command{
+	Use:   "breakout",
+	Short: "Move the active pane into its own tab",
+	Long: `Move the focused kitty window out of its tab into a new tab.
+
+The pane keeps running and stays part of its session. The session's save
+file is updated afterward so the new tab is restored.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := newState()
+		if err != nil {
+			return err
+		}
+		k := s.KittyClient()
+
+		kittyState, err := k.GetState()
+		if err != nil {
+			return fmt.Errorf("get kitty state: %w", err)
+		}
+
+		active := kitty.FindActiveWindow(kittyState)
+		if active == nil {
+			return fmt.Errorf("no active kitty window")
+		}
+		sessionName := active.UserVars["kmux_session"]
+		if sessionName == "" {
+			return fmt.Errorf("active window is not part of a kmux session")
+		}
+		if tab := kitty.FindActiveTab(kittyState); tab != nil && len(tab.Windows) == 1 {
+			return fmt.Errorf("window %d is already alone in its tab", active.ID)
+		}
+
+		if err := k.MoveWindowToTab(active.ID, 0); err != nil {
+			return fmt.Errorf("move window: %w", err)
+		}
+		if err := k.FocusWindow(active.ID); err != nil {
+			return fmt.Errorf("focus window: %w", err)
+		}
+
+		// Capture the new tab in the save file
+		kittyState, err = k.GetState()
+		if err != nil {
+			return fmt.Errorf("get kitty state: %w", err)
+		}
+		host := active.UserVars["kmux_host"]
+		if host == "" {
+			host = "local"
+		}
+		session := manager.DeriveConfiguredSession(s, sessionName, host, kittyState)
+		return saveDerivedSession(s, session, host)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(breakoutCmd)
+}
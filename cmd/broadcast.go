@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cwel/kmux/internal/store"
+	"github.com/spf13/cobra"
+)
+
+// broadcastConfirmThreshold is the pane count above which broadcast asks for confirmation.
+const broadcastConfirmThreshold = 4
+
+var (
+	broadcastHost string
+	broadcastYes  bool
+)
+
+var broadcastCmd = &cobra.Command{
+	Use:   "broadcast <session> -- <command...>",
+	Short: "Send a command to every pane of a session",
+	Long: `Send a command (followed by a newline) to every kitty window in a session.
+
+Asks for confirmation when more than 4 panes would receive it; use --yes to skip.
+
+Examples:
+  kmux broadcast cluster -- uptime
+  kmux broadcast cluster --host server -- git pull`,
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completeSessionNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := store.ValidateSessionName(name); err != nil {
+			return err
+		}
+		text := strings.Join(args[1:], " ") + "\n"
+
+		s, err := newState()
+		if err != nil {
+			return err
+		}
+		name, host, err := sessionHostArg(name, broadcastHost, s.ConfiguredHosts())
+		if err != nil {
+			return err
+		}
+		if host == "" {
+			if host, err = autoDetectSessionHost(s, name); err != nil {
+				return err
+			}
+		}
+
+		windows, err := s.GetWindowsForSessionOnHost(name, host)
+		if err != nil {
+			return fmt.Errorf("get session windows: %w", err)
+		}
+		if len(windows) == 0 {
+			return fmt.Errorf("session %q has no attached windows", name)
+		}
+
+		if len(windows) > broadcastConfirmThreshold && !broadcastYes {
+			fmt.Printf("Send to %d panes in %s? [y/N] ", len(windows), name)
+			answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			answer = strings.ToLower(strings.TrimSpace(answer))
+			if answer != "y" && answer != "yes" {
+				fmt.Println("Aborted")
+				return nil
+			}
+		}
+
+		k := s.KittyClient()
+		var sent int
+		for _, win := range windows {
+			if err := k.SendText(win.ID, text); err != nil {
+				fmt.Printf("Failed to send to window %d: %v\n", win.ID, err)
+				continue
+			}
+			sent++
+		}
+		fmt.Printf("Sent to %d/%d panes\n", sent, len(windows))
+		return nil
+	},
+}
+
+func init() {
+	broadcastCmd.Flags().StringVarP(&broadcastHost, "host", "H", "", "remote host (SSH alias, default: auto-detect)")
+	broadcastCmd.Flags().BoolVarP(&broadcastYes, "yes", "y", false, "skip the confirmation prompt")
+	rootCmd.AddCommand(broadcastCmd)
+}
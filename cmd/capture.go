@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cwel/kmux/internal/manager"
+	"github.com/cwel/kmux/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	captureAs    string
+	captureHost  string
+	captureForce bool
+)
+
+var captureCmd = &cobra.Command{
+	Use:   "capture <session> --as <layout>",
+	Short: "Save a session's current arrangement as a layout template",
+	Long: `Capture the tabs, panes and commands of a running session as a layout
+template in the config layouts directory, for use with 'kmux a --layout'.
+
+Tabs using kitty's splits layout are approximated by tall or fat, since
+templates only support simple layouts; a warning is printed when panes
+can't be arranged exactly.
+
+Examples:
+  kmux capture work --as dev
+  kmux capture work --as dev --force   # overwrite an existing layout`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSessionNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if captureAs == "" {
+			return fmt.Errorf("layout name required (use --as)")
+		}
+
+		s, err := newState()
+		if err != nil {
+			return err
+		}
+		name, host, err := sessionHostArg(name, captureHost, s.ConfiguredHosts())
+		if err != nil {
+			return err
+		}
+		if host == "" {
+			host = "local"
+		}
+		kittyState, err := s.KittyClient().GetState()
+		if err != nil {
+			return fmt.Errorf("get kitty state: %w", err)
+		}
+
+		session := manager.DeriveConfiguredSession(s, name, host, kittyState)
+		if len(session.Tabs) == 0 {
+			return fmt.Errorf("session %s has no attached windows", name)
+		}
+
+		layout, warnings := manager.SessionToLayout(session, captureAs)
+		for _, w := range warnings {
+			fmt.Printf("Warning: %s\n", w)
+		}
+
+		path, err := store.SaveLayout(layout, captureForce)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Saved layout %s to %s\n", captureAs, path)
+		return nil
+	},
+}
+
+func init() {
+	captureCmd.Flags().StringVar(&captureAs, "as", "", "name of the layout to write")
+	captureCmd.Flags().StringVarP(&captureHost, "host", "H", "", "host of the session (default: local)")
+	captureCmd.Flags().BoolVarP(&captureForce, "force", "f", false, "overwrite an existing layout")
+	rootCmd.AddCommand(captureCmd)
+}
@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -11,23 +13,63 @@ import (
 )
 
 // completeSessionNames returns session names for shell completion.
+// Each name is listed once, described by the hosts it lives on, e.g.
+// "myproject<TAB>local (active), devbox (detached)". Commands with a --host
+// flag also get name@host for every remote session. Hosts that don't answer
+// in time are left out; local sessions are still completed.
 func completeSessionNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-	s := state.New()
+	s, err := state.New()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
 	sessions, _ := s.AllSessions(ctx, true)
+	qualify := cmd.Flags().Lookup("host") != nil
+	return sessionCompletions(sessions, toComplete, qualify), cobra.ShellCompDirectiveNoFileComp
+}
 
-	seen := make(map[string]bool)
-	var names []string
+// sessionCompletions builds "name\tdescription" completions for sessions
+// matching prefix, merging sessions that share a name across hosts. With
+// qualify, each remote session is also offered once as "name@host".
+func sessionCompletions(sessions []state.SessionInfo, prefix string, qualify bool) []string {
+	var names, qualified []string
+	hosts := make(map[string][]string)
+	statuses := make(map[string]string)
 	for _, sess := range sessions {
-		if strings.HasPrefix(sess.Name, toComplete) && !seen[sess.Name] {
-			seen[sess.Name] = true
+		host := sess.Host
+		if host == "" {
+			host = "local"
+		}
+		if qualify && host != "local" {
+			key := sess.Name + "@" + host
+			if _, ok := statuses[key]; !ok && strings.HasPrefix(key, prefix) {
+				qualified = append(qualified, key)
+				statuses[key] = sess.Status
+			}
+		}
+		if !strings.HasPrefix(sess.Name, prefix) {
+			continue
+		}
+		desc := fmt.Sprintf("%s (%s)", host, sess.Status)
+		if _, ok := hosts[sess.Name]; !ok {
 			names = append(names, sess.Name)
+		} else if slices.Contains(hosts[sess.Name], desc) {
+			continue
 		}
+		hosts[sess.Name] = append(hosts[sess.Name], desc)
+	}
+
+	completions := make([]string, 0, len(names)+len(qualified))
+	for _, name := range names {
+		completions = append(completions, name+"\t"+strings.Join(hosts[name], ", "))
+	}
+	for _, key := range qualified {
+		completions = append(completions, key+"\t"+statuses[key])
 	}
-	return names, cobra.ShellCompDirectiveNoFileComp
+	return completions
 }
 
 var completionCmd = &cobra.Command{
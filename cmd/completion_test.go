@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cwel/kmux/internal/model"
+	"github.com/cwel/kmux/internal/state"
+	"github.com/cwel/kmux/internal/store"
+)
+
+func TestSessionCompletions(t *testing.T) {
+	sessions := []state.SessionInfo{
+		{Name: "work", Host: "local", Status: "active"},
+		{Name: "work", Host: "devbox", Status: "detached"},
+		{Name: "api", Host: "devbox", Status: "saved"},
+		{Name: "api", Host: "devbox", Status: "saved"}, // listed twice
+		{Name: "notes", Status: "saved"},
+	}
+
+	got := sessionCompletions(sessions, "", true)
+	want := []string{
+		"work\tlocal (active), devbox (detached)",
+		"api\tdevbox (saved)",
+		"notes\tlocal (saved)",
+		"work@devbox\tdetached",
+		"api@devbox\tsaved",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sessionCompletions() = %q, want %q", got, want)
+	}
+
+	// name@host candidates match the prefix as a whole
+	got = sessionCompletions(sessions, "work@", true)
+	if want := []string{"work@devbox\tdetached"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sessionCompletions(work@) = %q, want %q", got, want)
+	}
+
+	// Commands without --host only get bare names
+	got = sessionCompletions(sessions, "w", false)
+	if want := []string{"work\tlocal (active), devbox (detached)"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sessionCompletions(w, no host) = %q, want %q", got, want)
+	}
+}
+
+func TestCompleteSessionNames(t *testing.T) {
+	// Stub kitty and zmx have nothing running, so the local session comes
+	// from its save file. Stub ssh answers 'kmux session list' for devbox
+	// and fails for downbox, a host that is down.
+	dir := t.TempDir()
+	stubs := map[string]string{
+		"kitty": "#!/bin/sh\nexit 1\n",
+		"zmx":   "#!/bin/sh\necho \"no sessions found\" >&2\nexit 1\n",
+		"shell": "#!/bin/sh\nexec /bin/sh -c \"$2\"\n",
+		"ssh": `#!/bin/sh
+case "$*" in
+*"-O check"*) exit 0 ;;
+*downbox*) echo "ssh: connect to host downbox: Connection refused" >&2; exit 255 ;;
+*"session list"*) echo '[{"Name":"api","Host":"local","Status":"detached","Panes":1}]' ;;
+esac
+`,
+	}
+	for name, script := range stubs {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	configDir := filepath.Join(dir, "config")
+	if err := os.MkdirAll(configDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte("[hosts.devbox]\n[hosts.downbox]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("SHELL", filepath.Join(dir, "shell"))
+	t.Setenv("KMUX_KITTY_SOCKET", "unix:"+filepath.Join(dir, "kitty.sock"))
+	t.Setenv("KMUX_CONFIG_DIR", configDir)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+
+	saved := &model.Session{
+		Name: "work",
+		Host: "local",
+		Tabs: []model.Tab{{Title: "work", Layout: "splits", Windows: []model.Window{{CWD: "/tmp"}}}},
+	}
+	if err := store.DefaultStore().SaveSession(saved); err != nil {
+		t.Fatal(err)
+	}
+
+	// kill takes --host, so remote sessions are also offered as name@host;
+	// downbox contributes nothing instead of failing the completion
+	got, _ := completeSessionNames(killCmd, nil, "")
+	want := []string{"work\tlocal (saved)", "api\tdevbox (detached)", "api@devbox\tdetached"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("completeSessionNames(kill) = %q, want %q", got, want)
+	}
+
+	// session touch has no --host: bare names only
+	got, _ = completeSessionNames(sessionTouchCmd, nil, "a")
+	if want := []string{"api\tdevbox (detached)"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("completeSessionNames(touch) = %q, want %q", got, want)
+	}
+}
+
+func TestSplitSessionHost(t *testing.T) {
+	hosts := []string{"devbox"}
+	tests := []struct {
+		arg, name, host string
+	}{
+		{"work@devbox", "work", "devbox"},
+		{"work@local", "work", "local"},
+		{"me@example", "me@example", ""}, // not a configured host: part of the name
+		{"work", "work", ""},
+		{"work@", "work@", ""},
+		{"@devbox", "@devbox", ""},
+	}
+	for _, tt := range tests {
+		name, host := splitSessionHost(tt.arg, hosts)
+		if name != tt.name || host != tt.host {
+			t.Errorf("splitSessionHost(%q) = %q, %q, want %q, %q", tt.arg, name, host, tt.name, tt.host)
+		}
+	}
+}
@@ -48,6 +48,12 @@ var configInitCmd = &cobra.Command{
 		defaultConfig := `[kitty]
 # Socket path for kitty remote control (required if running kmux outside kitty)
 # socket = "/tmp/mykitty"
+# Overridden by --kitty-socket or KMUX_KITTY_SOCKET (e.g. with several kitty instances);
+# takes precedence over KITTY_LISTEN_ON when the socket exists
+# Pattern to search when the socket isn't found; $XDG_RUNTIME_DIR/kitty* is also searched
+# socket_glob = "/tmp/mykitty-*"
+# Attempts for transient remote control failures (e.g. during kitty startup)
+# retry_attempts = 3
 
 [projects]
 # Directories to scan for projects (shown in TUI)
@@ -55,6 +61,57 @@ var configInitCmd = &cobra.Command{
 # max_depth = 2
 # git_only = true  # only show git repos (set false to show all directories)
 # ignore = ["node_modules", "vendor", "~/src/old-stuff"]
+
+[browser]
+# File browser opened with z/Z in the TUI (must write the chosen path to a file)
+# command = "yazi"
+# chooser_flag = "--chooser-file="  # e.g. "-p " for nnn, "-selection-path=" for lf
+
+[remote]
+# Seconds to reuse a host's zmx session list before querying over SSH again
+# cache_ttl = 5
+# Milliseconds to wait for a reattached remote window before focusing it
+# (kitten ssh can otherwise leave focus on the local prompt; 0 disables)
+# focus_wait_ms = 1000
+
+# Remote hosts, keyed by SSH config alias
+# [hosts.devbox]
+# zmx_path = "zmx"
+# kmux_path = "kmux"
+# control_path = "/tmp/kmux-ssh-%r@%h:%p"  # ssh connection reuse ("none" disables)
+# ssh_target = "me@10.0.0.5"  # connect here instead of the alias (no SSH config entry needed)
+# port = 2222
+
+[tui]
+# Session status indicators: glyph and color (hex or ANSI number) per status
+# active_glyph = "●"
+# active_color = "#a6e3a1"
+# detached_glyph = "◐"
+# detached_color = "#a6e3a1"
+# saved_glyph = "○"
+# saved_color = "#6c7086"
+
+[sessions]
+# Session names (glob patterns) whose layout is never saved, e.g. scratch sessions.
+# A session can also opt out at launch with 'kmux a --no-save'.
+# Skipped saves are only reported with KMUX_DEBUG=1.
+# no_autosave = ["scratch*", "tmp-*"]
+# Window environment variables saved on detach and restored on reattach.
+# Only these keys are captured, so don't list secrets.
+# capture_env = ["EDITOR", "VIRTUAL_ENV", "NODE_ENV"]
+# How 'kmux a' names sessions after a directory, e.g. for ~/src/acme/api:
+#   basename -> api, parent-basename -> acme-api,
+#   git-root-basename -> name of the enclosing repo's root directory
+# name_strategy = "basename"
+# Programs (glob patterns on the command name) saved as a plain shell instead
+# of being re-run on restore, e.g. pagers and interactive viewers.
+# no_restore_commands = ["less", "more", "most", "man", "top", "htop", "btop"]
+# Days to keep the save files snapshotted by 'kmux kill --all' ('kmux trash list')
+# trash_days = 7
+
+[limits]
+# Refuse to create more panes than this in one session (0 = unlimited)
+# max_panes_per_session = 0
 `
 		if err := os.WriteFile(configPath, []byte(defaultConfig), 0644); err != nil {
 			return fmt.Errorf("write config: %w", err)
@@ -4,12 +4,14 @@ import (
 	"fmt"
 
 	"github.com/cwel/kmux/internal/manager"
-	"github.com/cwel/kmux/internal/state"
 	"github.com/cwel/kmux/internal/store"
 	"github.com/spf13/cobra"
 )
 
-var detachHost string
+var (
+	detachHost    string
+	detachNoClose bool
+)
 
 var detachCmd = &cobra.Command{
 	Use:     "detach [session]",
@@ -20,12 +22,17 @@ var detachCmd = &cobra.Command{
 If session name is provided, detaches that session.
 Otherwise detects current session from the active kitty window.
 
-Use --host to specify which host's session to detach (default: auto-detect or "local").`,
+Use --host to specify which host's session to detach (default: auto-detect or "local").
+
+Use --no-close to only save the layout and keep working in the session's
+windows; the session stays attached.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		s := state.New()
+		s, err := newState()
+		if err != nil {
+			return err
+		}
 		k := s.KittyClient()
-		st := s.Store()
 
 		// Get current kitty state (needed for detection and closing)
 		kittyState, err := k.GetState()
@@ -81,27 +88,18 @@ Use --host to specify which host's session to detach (default: auto-detect or "l
 		}
 
 		// Derive session from current state using user_vars (filtered by host)
-		session := manager.DeriveSession(sessionName, host, kittyState)
+		session := manager.DeriveConfiguredSession(s, sessionName, host, kittyState)
 
-		// Save session to the appropriate host
-		if host != "local" {
-			// Remote sees itself as local; CWDs from local kitty are meaningless on remote
-			session.Host = "local"
-			for i := range session.Tabs {
-				for j := range session.Tabs[i].Windows {
-					session.Tabs[i].Windows[j].CWD = ""
-				}
-			}
-			remoteClient := s.RemoteKmuxClient(host)
-			if remoteClient != nil {
-				if err := remoteClient.SaveSession(session); err != nil {
-					return fmt.Errorf("save remote session: %w", err)
-				}
-			}
-		} else {
-			if err := st.SaveSession(session); err != nil {
-				return fmt.Errorf("save session: %w", err)
+		if err := saveDerivedSession(s, session, host); err != nil {
+			return err
+		}
+
+		if detachNoClose {
+			if jsonOutput {
+				return printJSON(actionResult{Action: "saved", Session: sessionName, Host: host})
 			}
+			humanf("Saved session: %s\n", sessionName)
+			return nil
 		}
 
 		// Close windows belonging to this session AND host
@@ -122,10 +120,13 @@ Use --host to specify which host's session to detach (default: auto-detect or "l
 			}
 		}
 
+		if jsonOutput {
+			return printJSON(actionResult{Action: "detached", Session: sessionName, Host: host})
+		}
 		if host != "local" {
-			fmt.Printf("Detached from session: %s@%s\n", sessionName, host)
+			humanf("Detached from session: %s@%s\n", sessionName, host)
 		} else {
-			fmt.Printf("Detached from session: %s\n", sessionName)
+			humanf("Detached from session: %s\n", sessionName)
 		}
 		return nil
 	},
@@ -133,5 +134,6 @@ Use --host to specify which host's session to detach (default: auto-detect or "l
 
 func init() {
 	detachCmd.Flags().StringVarP(&detachHost, "host", "H", "", "remote host (SSH alias, default: auto-detect)")
+	detachCmd.Flags().BoolVar(&detachNoClose, "no-close", false, "save the layout but keep the session's windows open")
 	rootCmd.AddCommand(detachCmd)
 }
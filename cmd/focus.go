@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cwel/kmux/internal/kitty"
+	"github.com/spf13/cobra"
+)
+
+// focusDirections maps command directions to kitty's neighbor keys.
+var focusDirections = map[string]string{
+	"left":   "left",
+	"right":  "right",
+	"up":     "top",
+	"down":   "bottom",
+	"h":      "left",
+	"l":      "right",
+	"k":      "top",
+	"j":      "bottom",
+	"top":    "top",
+	"bottom": "bottom",
+}
+
+var focusCmd = &cobra.Command{
+	Use:       "focus <left|right|up|down>",
+	Short:     "Focus the neighboring pane in a direction",
+	Long:      "Focus the pane next to the active one, staying within the current session.\n\nVim-style h/j/k/l are accepted as directions.",
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"left", "right", "up", "down"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		neighborKey, ok := focusDirections[args[0]]
+		if !ok {
+			return fmt.Errorf("invalid direction: %s (use 'left', 'right', 'up', or 'down')", args[0])
+		}
+
+		s, err := newState()
+		if err != nil {
+			return err
+		}
+		k := s.KittyClient()
+
+		kittyState, err := k.GetState()
+		if err != nil {
+			return fmt.Errorf("get kitty state: %w", err)
+		}
+
+		active := kitty.FindActiveWindow(kittyState)
+		if active == nil {
+			return fmt.Errorf("no active kitty window")
+		}
+
+		// Only move to neighbors in the same session (and host)
+		for _, id := range active.Neighbors[neighborKey] {
+			neighbor := kitty.FindWindow(kittyState, id)
+			if neighbor == nil {
+				continue
+			}
+			if neighbor.UserVars["kmux_session"] != active.UserVars["kmux_session"] ||
+				neighbor.UserVars["kmux_host"] != active.UserVars["kmux_host"] {
+				continue
+			}
+			return k.FocusWindow(id)
+		}
+
+		return fmt.Errorf("no pane %s of the active window in this session", args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(focusCmd)
+}
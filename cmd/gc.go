@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cwel/kmux/internal/store"
+	"github.com/cwel/kmux/internal/zmx"
+	"github.com/spf13/cobra"
+)
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Drop ownership entries for dead zmx sessions",
+	Long: `Remove zmx ownership entries (kept so renamed sessions still own their
+zmx sessions) whose zmx session is no longer running locally.
+
+Run it after zmx sessions were killed outside kmux.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := newState()
+		if err != nil {
+			return err
+		}
+
+		// Without a reliable live set every entry would look stale
+		live, err := s.ZmxClient().List()
+		if err != nil && !errors.Is(err, zmx.ErrNoSessions) {
+			return fmt.Errorf("list zmx sessions: %w", err)
+		}
+
+		pruned, err := store.PruneOwnership(live)
+		if err != nil {
+			return fmt.Errorf("prune ownership: %w", err)
+		}
+		if len(pruned) == 0 {
+			fmt.Println("No stale ownership entries")
+			return nil
+		}
+		for _, zmxName := range pruned {
+			fmt.Printf("Removed %s\n", zmxName)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(gcCmd)
+}
@@ -2,21 +2,58 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/cwel/kmux/internal/config"
+	"github.com/cwel/kmux/internal/manager"
+	"github.com/cwel/kmux/internal/model"
+	"github.com/cwel/kmux/internal/remote"
 	"github.com/cwel/kmux/internal/state"
 )
 
+// splitSessionHost splits a name@host argument, the form completion offers
+// for remote sessions, when host is "local" or one of hosts. Anything else
+// (session names may contain @) is returned whole with an empty host.
+func splitSessionHost(arg string, hosts []string) (name, host string) {
+	i := strings.LastIndex(arg, "@")
+	if i <= 0 || i == len(arg)-1 {
+		return arg, ""
+	}
+	name, host = arg[:i], arg[i+1:]
+	if host == "local" || slices.Contains(hosts, host) {
+		return name, host
+	}
+	return arg, ""
+}
+
+// sessionHostArg resolves a session argument that may be name@host against
+// the command's --host value (flagHost, empty if not given).
+func sessionHostArg(arg, flagHost string, hosts []string) (name, host string, err error) {
+	name, host = splitSessionHost(arg, hosts)
+	if host == "" {
+		return arg, flagHost, nil
+	}
+	if flagHost != "" && flagHost != host {
+		return "", "", fmt.Errorf("host %s in %s conflicts with --host %s", host, arg, flagHost)
+	}
+	return name, host, nil
+}
+
 // autoDetectSessionHost finds which host has a session with the given name.
 // Returns:
 // - The host if session exists on exactly one host
-// - User's choice via fzf if session exists on multiple hosts
+// - User's choice via fzf (or the built-in picker) if session exists on multiple hosts
 // - "local" if session doesn't exist anywhere (will create new)
-func autoDetectSessionHost(s *state.State, name string) string {
+// An ambiguous name is an error when no choice can be made interactively,
+// so a session is never silently attached, killed or created on the wrong host.
+func autoDetectSessionHost(s *state.State, name string) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -24,26 +61,53 @@ func autoDetectSessionHost(s *state.State, name string) string {
 	allSessions, _ := s.AllSessions(ctx, true)
 
 	// Find which hosts have this session
-	var hostsWithSession []string
+	var matches []state.SessionInfo
 	for _, sess := range allSessions {
 		if sess.Name == name {
-			hostsWithSession = append(hostsWithSession, sess.Host)
+			matches = append(matches, sess)
 		}
 	}
 
-	switch len(hostsWithSession) {
+	switch len(matches) {
 	case 0:
-		return "local"
+		return "local", nil
 	case 1:
-		return hostsWithSession[0]
+		return matches[0].Host, nil
 	default:
-		// Multiple hosts - use fzf to pick
-		return pickHostWithFzf(name, hostsWithSession)
+		return pickSessionHost(name, matches)
 	}
 }
 
+// pickSessionHost asks which of several hosts to use for a session, with fzf
+// when installed and the numbered picker otherwise.
+func pickSessionHost(name string, matches []state.SessionInfo) (string, error) {
+	hosts := make([]string, len(matches))
+	for i, sess := range matches {
+		hosts[i] = sess.Host
+	}
+
+	host, err := pickHostWithFzf(name, hosts)
+	if err == nil {
+		return host, nil
+	}
+	if !errors.Is(err, exec.ErrNotFound) {
+		return "", fmt.Errorf("no host picked for %s", name)
+	}
+
+	if !stdinIsTerminal() {
+		return "", fmt.Errorf("session %s exists on multiple hosts (%s), choose one with --host",
+			name, strings.Join(hosts, ", "))
+	}
+	fmt.Fprintf(os.Stderr, "Session '%s' exists on multiple hosts:\n", name)
+	picked, err := pickFromSessions(matches)
+	if err != nil {
+		return "", err
+	}
+	return picked.Host, nil
+}
+
 // pickHostWithFzf prompts user to select a host using fzf.
-func pickHostWithFzf(sessionName string, hosts []string) string {
+func pickHostWithFzf(sessionName string, hosts []string) (string, error) {
 	input := strings.Join(hosts, "\n")
 	height := fmt.Sprintf("%d", len(hosts)+2) // just enough for entries + prompt
 	cmd := exec.Command("fzf",
@@ -55,11 +119,72 @@ func pickHostWithFzf(sessionName string, hosts []string) string {
 
 	output, err := cmd.Output()
 	if err != nil {
-		// fzf cancelled or not available - default to first host
-		fmt.Printf("Multiple hosts have session '%s': %s\n", sessionName, strings.Join(hosts, ", "))
-		fmt.Printf("Using: %s (specify with --host to override)\n", hosts[0])
-		return hosts[0]
+		return "", err // fzf cancelled or not available
 	}
 
-	return strings.TrimSpace(string(output))
+	return strings.TrimSpace(string(output)), nil
+}
+
+// stdinIsTerminal reports whether stdin is an interactive terminal.
+func stdinIsTerminal() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// loadConfig loads the config file, naming it in the error so a broken
+// config isn't mistaken for missing hosts or projects.
+func loadConfig() (*config.Config, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w\nfix or remove %s", err, filepath.Join(config.ConfigDir(), "config.toml"))
+	}
+	return cfg, nil
+}
+
+// newState creates the State for a command from the config file.
+func newState() (*state.State, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return state.NewWithConfig(cfg), nil
+}
+
+// remoteKmuxClient returns the kmux client for a configured remote host.
+func remoteKmuxClient(s *state.State, host string) (*remote.Client, error) {
+	client := s.RemoteKmuxClient(host)
+	if client == nil {
+		return nil, fmt.Errorf("unknown host: %s (not in [hosts] config)", host)
+	}
+	return client, nil
+}
+
+// saveDerivedSession saves a session derived from kitty state to the host it belongs to.
+// Sessions excluded from saving (see manager.SaveExcluded) are skipped.
+func saveDerivedSession(s *state.State, session *model.Session, host string) error {
+	if manager.SaveExcluded(s, session) {
+		debugf("not saving %s (excluded from auto-save)\n", session.Name)
+		return nil
+	}
+	if host == "local" {
+		if err := s.Store().SaveSession(session); err != nil {
+			return fmt.Errorf("save session: %w", err)
+		}
+		return nil
+	}
+
+	// Remote sees itself as local; CWDs from local kitty are meaningless on remote
+	session.Host = "local"
+	for i := range session.Tabs {
+		for j := range session.Tabs[i].Windows {
+			session.Tabs[i].Windows[j].CWD = ""
+		}
+	}
+	remoteClient := s.RemoteKmuxClient(host)
+	if remoteClient != nil {
+		if err := remoteClient.SaveSession(session); err != nil {
+			return fmt.Errorf("save remote session: %w", err)
+		}
+	}
+	return nil
 }
@@ -1,27 +1,44 @@
 package cmd
 
 import (
+	"bufio"
 	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/cwel/kmux/internal/manager"
+	"github.com/cwel/kmux/internal/model"
 	"github.com/cwel/kmux/internal/state"
 	"github.com/cwel/kmux/internal/store"
 	"github.com/spf13/cobra"
 )
 
 var (
-	killAll  bool
-	killHost string
+	killAll    bool
+	killHost   string
+	killDryRun bool
+	killYes    bool
+	killZmx    bool
 )
 
 var killCmd = &cobra.Command{
 	Use:               "kill <name>... | --all",
 	Aliases:           []string{"k", "rm"},
 	Short:             "Kill sessions",
-	Long:              "Terminate zmx sessions and delete saved state. Use --all or * to kill all sessions including restore points.\n\nUse --host to specify which host's session to kill (default: local).",
+	Long:              "Terminate zmx sessions and delete saved state. Use --all or * to kill all sessions including restore points.\n\nUse --dry-run to preview the windows, zmx sessions and save files that would be removed.\n\nBefore --all kills anything it asks for confirmation (skip with --yes, which is required when stdin is not a terminal) and copies each session to the trash (its live layout, or its save file, also for --host), so it can be brought back with 'kmux trash restore'.\n\nUse --zmx-only to free a session's processes but keep its save file: the session shows as saved in 'kmux ls -a' and 'kmux a' recreates it later. Unlike detach, which first saves the live layout, --zmx-only keeps the save file as it is.\n\nUse --host (or name@host) to specify which host's session to kill (default: local).",
 	Args:              cobra.ArbitraryArgs,
 	ValidArgsFunction: completeSessionNames,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		s := state.New()
+		s, err := newState()
+		if err != nil {
+			return err
+		}
+
+		var targets []actionResult
+		var results []actionResult // --json output
 
 		// Handle --all or * argument
 		if killAll || (len(args) == 1 && args[0] == "*") {
@@ -33,68 +50,211 @@ var killCmd = &cobra.Command{
 			if err != nil {
 				return fmt.Errorf("list sessions: %w", err)
 			}
-			var names []string
 			for _, sess := range sessions {
 				// Only include sessions for the specified host
 				if sess.Host == host {
-					names = append(names, sess.Name)
+					targets = append(targets, actionResult{Session: sess.Name, Host: host})
 				}
 			}
-			if len(names) == 0 {
-				fmt.Println("No sessions to kill")
-				return nil
+			if len(targets) == 0 {
+				humanf("No sessions to kill\n")
+			}
+		} else {
+			if len(args) == 0 {
+				return fmt.Errorf("session name required (or use --all)")
 			}
 
-			var killed int
-			for _, name := range names {
-				if err := killSessionWithHost(s, name, host); err != nil {
-					fmt.Printf("Failed to kill %s: %v\n", name, err)
-					continue
+			// Validate all names first
+			for _, name := range args {
+				if err := store.ValidateSessionName(name); err != nil {
+					return err
 				}
-				killed++
 			}
-			if len(names) > 1 {
-				fmt.Printf("Killed %d sessions\n", killed)
+
+			// Auto-detect each session's host if not specified
+			for _, arg := range args {
+				name, host, err := sessionHostArg(arg, killHost, s.ConfiguredHosts())
+				if err != nil {
+					return err
+				}
+				if host == "" {
+					if host, err = autoDetectSessionHost(s, name); err != nil {
+						humanf("Failed to kill %s: %v\n", name, err)
+						results = append(results, actionResult{Action: "kill", Session: name, Error: err.Error()})
+						continue
+					}
+				}
+				targets = append(targets, actionResult{Session: name, Host: host})
 			}
-			return nil
 		}
 
-		if len(args) == 0 {
-			return fmt.Errorf("session name required (or use --all)")
+		if killDryRun {
+			plans := make([]*manager.KillPlan, 0, len(targets))
+			for _, t := range targets {
+				plans = append(plans, manager.PlanKill(s, manager.KillOpts{Name: t.Session, Host: t.Host, KeepSaveFile: killZmx}))
+			}
+			if jsonOutput {
+				return printJSON(plans)
+			}
+			for _, plan := range plans {
+				printKillPlan(plan, killZmx)
+			}
+			return nil
 		}
 
-		// Validate all names first
-		for _, name := range args {
-			if err := store.ValidateSessionName(name); err != nil {
-				return err
+		if killAll || (len(args) == 1 && args[0] == "*") {
+			if len(targets) > 0 && !killYes {
+				if !stdinIsTerminal() {
+					return fmt.Errorf("--all kills every session including restore points; pass --yes to confirm")
+				}
+				fmt.Fprintf(os.Stderr, "Kill all %d sessions? [y/N] ", len(targets))
+				answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+				answer = strings.ToLower(strings.TrimSpace(answer))
+				if answer != "y" && answer != "yes" {
+					fmt.Fprintln(os.Stderr, "Aborted")
+					return nil
+				}
+			}
+			if !killZmx {
+				if err := trashSaveFiles(s, targets); err != nil {
+					return fmt.Errorf("snapshot save files (nothing was killed): %w", err)
+				}
 			}
 		}
 
-		// Kill each session, auto-detecting host if not specified
 		var killed int
-		for _, name := range args {
-			host := killHost
-			if host == "" {
-				// Auto-detect which host has this session
-				host = autoDetectSessionHost(s, name)
+		for _, t := range targets {
+			t.Action = "killed"
+			if err := killSessionWithHost(s, t.Session, t.Host, killZmx); err != nil {
+				humanf("Failed to kill %s: %v\n", t.Session, err)
+				t.Action, t.Error = "kill", err.Error()
+			} else {
+				killed++
 			}
+			results = append(results, t)
+		}
 
-			if err := killSessionWithHost(s, name, host); err != nil {
-				fmt.Printf("Failed to kill %s: %v\n", name, err)
+		if jsonOutput {
+			if results == nil {
+				results = []actionResult{}
+			}
+			return printJSON(results)
+		}
+		if len(targets) > 1 {
+			humanf("Killed %d sessions\n", killed)
+		}
+		return nil
+	},
+}
+
+// trashSaveFiles copies a snapshot of each target to the trash and drops
+// trash older than [sessions] trash_days. Live sessions are snapshotted as
+// they are laid out now, others from their save file, which for remote
+// hosts is fetched from the host's kmux. Remote sessions keep their host,
+// so 'kmux trash restore' sends them back there.
+func trashSaveFiles(s *state.State, targets []actionResult) error {
+	st := s.Store()
+	saved, err := st.ListSessions()
+	if err != nil {
+		return err
+	}
+	kittyState, _ := s.KittyClient().GetState()
+
+	bundle := &store.Bundle{Ownership: make(map[string]string)}
+	for _, t := range targets {
+		var session *model.Session
+		if derived := manager.DeriveConfiguredSession(s, t.Session, t.Host, kittyState); len(derived.Tabs) > 0 {
+			session = derived
+			if t.Host != "local" {
+				// CWDs from local kitty are meaningless on the remote
+				for i := range session.Tabs {
+					for j := range session.Tabs[i].Windows {
+						session.Tabs[i].Windows[j].CWD = ""
+					}
+				}
+			}
+		} else if t.Host == "local" {
+			if !slices.Contains(saved, t.Session) {
 				continue
 			}
-			killed++
+			if session, err = st.LoadSession(t.Session); err != nil {
+				return err
+			}
+		} else {
+			client, err := remoteKmuxClient(s, t.Host)
+			if err != nil {
+				return err
+			}
+			if session, err = client.GetSession(t.Session); err != nil {
+				// Only zmx sessions, no save file on the host: nothing to keep
+				humanf("Warning: no save file of %s on %s to trash: %v\n", t.Session, t.Host, err)
+				continue
+			}
+			session.Host = t.Host
 		}
 
-		if len(args) > 1 {
-			fmt.Printf("Killed %d sessions\n", killed)
+		bundle.Sessions = append(bundle.Sessions, session)
+		if t.Host != "local" {
+			continue // the ownership file only covers local zmx sessions
 		}
+		for _, zmxName := range append(session.ZmxSessions, store.GetZmxSessionsForSession(t.Session)...) {
+			bundle.Ownership[zmxName] = t.Session
+		}
+	}
+	if len(bundle.Sessions) == 0 {
 		return nil
-	},
+	}
+
+	id, err := st.WriteTrash(bundle, time.Now())
+	if err != nil {
+		return err
+	}
+	humanf("Saved %d sessions to trash (restore with 'kmux trash restore %s')\n", len(bundle.Sessions), id)
+
+	if _, err := st.PruneTrash(trashRetention(s), time.Now()); err != nil {
+		humanf("Warning: prune trash: %v\n", err)
+	}
+	return nil
+}
+
+// printKillPlan describes what killing a session would do. keepSaveFile
+// reports a --zmx-only kill.
+func printKillPlan(plan *manager.KillPlan, keepSaveFile bool) {
+	label := plan.Name
+	if plan.Host != "local" {
+		label += "@" + plan.Host
+	}
+	humanf("Would kill %s\n", label)
+	if len(plan.WindowIDs) > 0 {
+		ids := make([]string, len(plan.WindowIDs))
+		for i, id := range plan.WindowIDs {
+			ids[i] = strconv.Itoa(id)
+		}
+		humanf("  kitty windows: %s\n", strings.Join(ids, ", "))
+	}
+	if plan.Host != "local" {
+		if keepSaveFile {
+			humanf("  zmx sessions:  handled by kmux on %s (save file kept)\n", plan.Host)
+		} else {
+			humanf("  zmx sessions and save file: handled by kmux on %s\n", plan.Host)
+		}
+		return
+	}
+	if len(plan.ZmxSessions) > 0 {
+		humanf("  zmx sessions:  %s\n", strings.Join(plan.ZmxSessions, ", "))
+	}
+	if plan.SaveFile {
+		humanf("  save file:     delete\n")
+	} else if keepSaveFile {
+		humanf("  save file:     keep\n")
+	}
 }
 
 func init() {
 	killCmd.Flags().BoolVarP(&killAll, "all", "a", false, "Kill all sessions including restore points")
+	killCmd.Flags().BoolVarP(&killDryRun, "dry-run", "n", false, "Show what would be killed without killing anything")
+	killCmd.Flags().BoolVarP(&killYes, "yes", "y", false, "don't ask for confirmation before --all")
+	killCmd.Flags().BoolVar(&killZmx, "zmx-only", false, "kill processes and close windows but keep the save file")
 	killCmd.Flags().StringVarP(&killHost, "host", "H", "", "remote host (SSH alias, default: local)")
 	rootCmd.AddCommand(killCmd)
 }
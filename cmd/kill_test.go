@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cwel/kmux/internal/model"
+	"github.com/cwel/kmux/internal/store"
+)
+
+func TestKillAllRequiresYesWithoutTerminal(t *testing.T) {
+	// Stub kitty and zmx fail every call, so only the save file is listed;
+	// the stub zmx is reached through a stub $SHELL
+	dir := t.TempDir()
+	stubs := map[string]string{
+		"kitty": "#!/bin/sh\nexit 1\n",
+		"zmx":   "#!/bin/sh\necho \"no sessions found\" >&2\nexit 1\n",
+		"shell": "#!/bin/sh\nexec /bin/sh -c \"$2\"\n",
+	}
+	for name, script := range stubs {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("SHELL", filepath.Join(dir, "shell"))
+	t.Setenv("KMUX_KITTY_SOCKET", "unix:"+filepath.Join(dir, "kitty.sock"))
+	t.Setenv("KMUX_CONFIG_DIR", filepath.Join(dir, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+
+	st := store.DefaultStore()
+	saved := &model.Session{
+		Name: "work",
+		Host: "local",
+		Tabs: []model.Tab{{Title: "work", Layout: "splits", Windows: []model.Window{{CWD: "/tmp"}}}},
+	}
+	if err := st.SaveSession(saved); err != nil {
+		t.Fatal(err)
+	}
+
+	// A regular file is not a terminal, like stdin in a script
+	stdin, err := os.Create(filepath.Join(dir, "stdin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+	oldStdin := os.Stdin
+	os.Stdin = stdin
+	defer func() { os.Stdin = oldStdin }()
+	defer func() { killAll = false }()
+
+	rootCmd.SetOut(io.Discard)
+	rootCmd.SetErr(io.Discard)
+	defer rootCmd.SetOut(nil)
+	defer rootCmd.SetErr(nil)
+	rootCmd.SetArgs([]string{"kill", "--all", "--quiet"})
+	err = rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "--yes") {
+		t.Fatalf("kill --all error = %v, want it to require --yes", err)
+	}
+	if _, err := st.LoadSession("work"); err != nil {
+		t.Errorf("save file was removed without confirmation: %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/cwel/kmux/internal/config"
+	"github.com/cwel/kmux/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var layoutCmd = &cobra.Command{
+	Use:   "layout",
+	Short: "Manage layout templates",
+	Long: `List, inspect and edit the layout templates used by 'kmux a --layout'.
+
+User layouts in the config layouts directory take precedence over bundled ones.`,
+}
+
+var layoutListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List available layouts",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		names, err := store.ListLayouts()
+		if err != nil {
+			return fmt.Errorf("list layouts: %w", err)
+		}
+		if len(names) == 0 {
+			fmt.Println("No layouts found (install the bundled ones with 'kmux config init')")
+			return nil
+		}
+
+		for _, name := range names {
+			layout, err := store.LoadLayout(name)
+			if err != nil {
+				fmt.Printf("%-16s (invalid: %v)\n", name, err)
+				continue
+			}
+			fmt.Printf("%-16s %s\n", name, layout.Description)
+		}
+		return nil
+	},
+}
+
+var layoutShowCmd = &cobra.Command{
+	Use:               "show <name>",
+	Short:             "Print a layout's YAML",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeLayoutNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := store.LayoutPath(args[0])
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read layout: %w", err)
+		}
+		fmt.Printf("# %s\n%s", path, data)
+		return nil
+	},
+}
+
+var layoutEditCmd = &cobra.Command{
+	Use:   "edit <name>",
+	Short: "Edit a layout in $EDITOR",
+	Long: `Open a layout in $EDITOR. Bundled layouts are first copied into the
+config layouts directory, so the edit overrides them without touching the
+originals. The layout is validated after the editor exits.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeLayoutNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := store.EnsureUserLayout(args[0])
+		if err != nil {
+			return err
+		}
+
+		editor := strings.Fields(os.Getenv("EDITOR"))
+		if len(editor) == 0 {
+			editor = []string{"vi"}
+		}
+		edit := exec.Command(editor[0], append(editor[1:], path)...)
+		edit.Stdin = os.Stdin
+		edit.Stdout = os.Stdout
+		edit.Stderr = os.Stderr
+		if err := edit.Run(); err != nil {
+			return fmt.Errorf("run editor: %w", err)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read layout: %w", err)
+		}
+		layout, err := config.ParseLayout(data)
+		if err == nil {
+			err = layout.Validate()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s is invalid: %v\n", path, err)
+		}
+		return nil
+	},
+}
+
+// completeLayoutNames returns layout names for shell completion.
+func completeLayoutNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names, _ := store.ListLayouts()
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	layoutCmd.AddCommand(layoutListCmd)
+	layoutCmd.AddCommand(layoutShowCmd)
+	layoutCmd.AddCommand(layoutEditCmd)
+	rootCmd.AddCommand(layoutCmd)
+}
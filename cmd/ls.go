@@ -4,7 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"text/tabwriter"
 	"time"
 
@@ -13,50 +17,123 @@ import (
 )
 
 var (
-	lsAll   bool
-	lsLocal bool
-	lsJSON  bool
+	lsAll      bool
+	lsLocal    bool
+	lsWatch    bool
+	lsInterval time.Duration
+	lsSort     string
+	lsReverse  bool
 )
 
 var lsCmd = &cobra.Command{
 	Use:     "ls",
 	Aliases: []string{"l", "list"},
 	Short:   "List sessions",
-	Long:    "List running sessions. Use --all to include restore points.",
+	Long:    "List running sessions. Use --all to include restore points.\n\nUse --watch to keep refreshing the table until interrupted.\n\nSessions are sorted by name; use --sort name|status|panes|last-seen and --reverse to change that.",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		s := state.New()
+		if err := state.SortSessions(nil, lsSort, lsReverse); err != nil {
+			return err
+		}
 
-		var sessions []state.SessionInfo
-		var err error
+		s, err := newState()
+		if err != nil {
+			return err
+		}
 
-		if lsLocal {
-			sessions, err = s.Sessions(lsAll)
-		} else {
-			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-			defer cancel()
-			sessions, err = s.AllSessions(ctx, lsAll)
+		if lsWatch {
+			return watchSessions(s)
 		}
 
+		sessions, err := listSessions(s)
 		if err != nil {
 			return err
 		}
 
-		if lsJSON {
+		if jsonOutput {
 			return printSessionsJSON(sessions)
 		}
 
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "SESSION\tHOST\tSTATUS\tPANES")
+		printSessionsTable(os.Stdout, sessions, nil)
+		return nil
+	},
+}
+
+// listSessions queries sessions according to the --local and --all flags,
+// ordered by --sort and --reverse.
+func listSessions(s *state.State) ([]state.SessionInfo, error) {
+	var sessions []state.SessionInfo
+	var err error
+	if lsLocal {
+		sessions, err = s.Sessions(lsAll)
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		sessions, err = s.AllSessions(ctx, lsAll)
+	}
+	if sortErr := state.SortSessions(sessions, lsSort, lsReverse); sortErr != nil {
+		return nil, sortErr
+	}
+	return sessions, err
+}
+
+// printSessionsTable writes sessions as aligned columns.
+// If prev is non-nil, status changes since the previous frame are annotated.
+func printSessionsTable(out io.Writer, sessions []state.SessionInfo, prev map[string]string) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SESSION\tHOST\tSTATUS\tPANES")
+	for _, sess := range sessions {
+		host := sess.Host
+		if host == "" {
+			host = "local"
+		}
+		status := sess.Status
+		if old, ok := prev[host+"/"+sess.Name]; ok && old != sess.Status {
+			status = fmt.Sprintf("%s (was %s)", sess.Status, old)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", sess.Name, host, status, sess.Panes)
+	}
+	w.Flush()
+}
+
+// watchSessions re-renders the session table every --interval until interrupted.
+func watchSessions(s *state.State) error {
+	if lsInterval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ticker := time.NewTicker(lsInterval)
+	defer ticker.Stop()
+
+	var prev map[string]string
+	for {
+		sessions, err := listSessions(s)
+
+		fmt.Print("\033[H\033[2J") // clear screen
+		fmt.Printf("Every %s: kmux ls  (%s)\n\n", lsInterval, time.Now().Format("15:04:05"))
+		printSessionsTable(os.Stdout, sessions, prev)
+		if err != nil {
+			fmt.Printf("\nerror: %v\n", err)
+		}
+
+		current := make(map[string]string, len(sessions))
 		for _, sess := range sessions {
 			host := sess.Host
 			if host == "" {
 				host = "local"
 			}
-			fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", sess.Name, host, sess.Status, sess.Panes)
+			current[host+"/"+sess.Name] = sess.Status
 		}
-		w.Flush()
-		return nil
-	},
+		prev = current
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
 }
 
 type sessionJSON struct {
@@ -88,6 +165,9 @@ func printSessionsJSON(sessions []state.SessionInfo) error {
 func init() {
 	lsCmd.Flags().BoolVarP(&lsAll, "all", "a", false, "Include restore points (saved sessions without running zmx)")
 	lsCmd.Flags().BoolVarP(&lsLocal, "local", "L", false, "Only show local sessions (skip remote hosts)")
-	lsCmd.Flags().BoolVar(&lsJSON, "json", false, "Output as JSON")
+	lsCmd.Flags().BoolVarP(&lsWatch, "watch", "w", false, "Refresh the table until interrupted")
+	lsCmd.Flags().DurationVar(&lsInterval, "interval", 2*time.Second, "Refresh interval for --watch")
+	lsCmd.Flags().StringVar(&lsSort, "sort", "name", "Sort by "+strings.Join(state.SortKeys, ", "))
+	lsCmd.Flags().BoolVarP(&lsReverse, "reverse", "r", false, "Reverse the sort order")
 	rootCmd.AddCommand(lsCmd)
 }
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cwel/kmux/internal/manager"
+	"github.com/cwel/kmux/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	moveSessionTo     string
+	moveSessionDelete bool
+)
+
+var moveSessionCmd = &cobra.Command{
+	Use:   "move-session <name> --to <host>",
+	Short: "Recreate a detached local session on a remote host",
+	Long: `Recreate a saved local session on a remote host, detached.
+
+The save file is pushed to the host and its zmx sessions are started
+there. Only the layout moves: running processes stay where they are, so
+the session must not be active locally.
+
+Examples:
+  kmux move-session api --to devbox
+  kmux move-session api --to devbox --delete   # drop the local save file`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSessionNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := store.ValidateSessionName(name); err != nil {
+			return err
+		}
+		if moveSessionTo == "" {
+			return fmt.Errorf("target host required (use --to)")
+		}
+
+		s, err := newState()
+		if err != nil {
+			return err
+		}
+		if err := manager.MoveSession(s, name, moveSessionTo); err != nil {
+			return err
+		}
+		fmt.Printf("Moved session: %s -> %s@%s (detached)\n", name, name, moveSessionTo)
+
+		if moveSessionDelete {
+			if err := s.Store().DeleteSession(name); err != nil {
+				return fmt.Errorf("delete local save file: %w", err)
+			}
+			fmt.Printf("Deleted local save file: %s\n", name)
+		}
+		return nil
+	},
+}
+
+func init() {
+	moveSessionCmd.Flags().StringVar(&moveSessionTo, "to", "", "remote host to move the session to (SSH alias from config)")
+	moveSessionCmd.Flags().BoolVar(&moveSessionDelete, "delete", false, "delete the local save file after moving")
+	rootCmd.AddCommand(moveSessionCmd)
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cwel/kmux/internal/kitty"
+	"github.com/cwel/kmux/internal/manager"
+	"github.com/cwel/kmux/internal/model"
+	"github.com/cwel/kmux/internal/store"
+	"github.com/cwel/kmux/internal/zmx"
+	"github.com/spf13/cobra"
+)
+
+var (
+	newWindowTab     bool
+	newWindowSession string
+	newWindowCwd     string
+)
+
+var newWindowCmd = &cobra.Command{
+	Use:   "new-window",
+	Short: "Add a window or tab to a session",
+	Long: `Add a zmx-backed window to a session.
+
+By default a new pane is added to the current tab of the session the focused
+window belongs to. With --tab, a whole new tab is added instead.
+
+Indices for the new zmx session ({session}.{tab}.{window}) are computed from
+the session's existing windows so they never collide.
+
+Examples:
+  kmux new-window                   # new pane in the current tab
+  kmux new-window --tab             # new tab in the current session
+  kmux new-window --tab -s work     # new tab in session "work"`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := newState()
+		if err != nil {
+			return err
+		}
+		k := s.KittyClient()
+
+		kittyState, err := k.GetState()
+		if err != nil {
+			return fmt.Errorf("get kitty state: %w", err)
+		}
+
+		active := kitty.FindActiveWindow(kittyState)
+		sessionName := newWindowSession
+		var host, remoteCWD string
+		if sessionName == "" && active != nil {
+			sessionName = active.UserVars["kmux_session"]
+		}
+		if sessionName == "" {
+			return fmt.Errorf("session name required (use --session or run from within a session)")
+		}
+		if err := store.ValidateSessionName(sessionName); err != nil {
+			return err
+		}
+
+		// Prefer the focused window's context when it belongs to the session
+		inSession := active != nil && active.UserVars["kmux_session"] == sessionName
+		if inSession {
+			host = active.UserVars["kmux_host"]
+			remoteCWD = active.UserVars["REMOTE_CWD"]
+		}
+		if !newWindowTab && !inSession {
+			return fmt.Errorf("focus a window in session %s to add a pane, or use --tab", sessionName)
+		}
+
+		// Collect zmx names already used by the session
+		var used []string
+		for _, osWin := range kittyState {
+			for _, tab := range osWin.Tabs {
+				for _, win := range tab.Windows {
+					if win.UserVars["kmux_session"] != sessionName {
+						continue
+					}
+					if host == "" {
+						host = win.UserVars["kmux_host"]
+					}
+					if zmxName := win.UserVars["kmux_zmx"]; zmxName != "" {
+						used = append(used, zmxName)
+					}
+				}
+			}
+		}
+		if host == "" {
+			host = "local"
+		}
+		if len(used) == 0 {
+			return fmt.Errorf("no windows found for session: %s", sessionName)
+		}
+		if err := manager.CheckPaneLimit(s, sessionName, len(used), 1); err != nil {
+			return err
+		}
+		// Detached zmx sessions also occupy indices
+		if running, err := s.SessionZmxSessionsForHost(sessionName, host); err == nil {
+			used = append(used, running...)
+		}
+
+		var zmxName string
+		opts := kitty.LaunchOpts{CWD: newWindowCwd}
+		if newWindowTab {
+			nextTab := 0
+			for _, name := range used {
+				if tabIdx, _, ok := model.ParseZmxIndices(name); ok && tabIdx >= nextTab {
+					nextTab = tabIdx + 1
+				}
+			}
+			zmxName = fmt.Sprintf("%s.%d.0", sessionName, nextTab)
+			opts.Type = "tab"
+			opts.Title = sessionName
+		} else {
+			tabIdx, _, ok := model.ParseZmxIndices(active.UserVars["kmux_zmx"])
+			if !ok {
+				return fmt.Errorf("focused window has no kmux zmx session")
+			}
+			nextWin := 0
+			for _, name := range used {
+				if t, winIdx, ok := model.ParseZmxIndices(name); ok && t == tabIdx && winIdx >= nextWin {
+					nextWin = winIdx + 1
+				}
+			}
+			zmxName = fmt.Sprintf("%s.%d.%d", sessionName, tabIdx, nextWin)
+			opts.Type = "window"
+		}
+
+		zmxClient := s.ZmxClientForHost(host)
+		if zmxClient.IsRemote() && remoteCWD != "" {
+			// Use REMOTE_CWD user var (set by shell integration on remote)
+			opts.Cmd = zmxClient.AttachCmd(zmxName, zmx.CWDCommand(remoteCWD))
+		} else {
+			opts.Cmd = zmxClient.AttachCmd(zmxName)
+		}
+
+		opts.Vars = map[string]string{
+			"kmux_zmx":     zmxName,
+			"kmux_session": sessionName,
+		}
+		if host != "local" {
+			opts.Vars["kmux_host"] = host
+		}
+
+		windowID, err := k.Launch(opts)
+		if err != nil {
+			return fmt.Errorf("launch %s: %w", opts.Type, err)
+		}
+
+		if host == "local" {
+			// The window is up either way, so report the missing entry instead of failing
+			if err := store.SetZmxOwnership(map[string]string{zmxName: sessionName}); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: record ownership of %s: %v\n", zmxName, err)
+			}
+		}
+
+		fmt.Printf("Created %s %s (window %d)\n", opts.Type, zmxName, windowID)
+		return nil
+	},
+}
+
+func init() {
+	newWindowCmd.Flags().BoolVarP(&newWindowTab, "tab", "t", false, "add a new tab instead of a pane")
+	newWindowCmd.Flags().StringVarP(&newWindowSession, "session", "s", "", "session to add to (default: current session)")
+	newWindowCmd.Flags().StringVar(&newWindowCwd, "cwd", "current", "Working directory (current, last_reported, oldest, root, or path)")
+	rootCmd.AddCommand(newWindowCmd)
+}
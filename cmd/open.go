@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cwel/kmux/internal/config"
+	"github.com/cwel/kmux/internal/manager"
+	"github.com/cwel/kmux/internal/project"
+	"github.com/cwel/kmux/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var openLayout string
+
+var openCmd = &cobra.Command{
+	Use:   "open <project | path>",
+	Short: "Open a project as a session",
+	Long: `Attach to a session for a project found in [projects] directories,
+named after the project and starting in its directory, like picking it in
+the TUI.
+
+When several projects share a name, the candidates are listed; pass the
+project's path instead to pick one.
+
+Examples:
+  kmux open api
+  kmux open api --layout dev
+  kmux open ~/src/acme/api   # disambiguate by path`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeProjectNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		query := args[0]
+		if strings.HasPrefix(query, "~") || strings.ContainsRune(query, '/') {
+			if query, err = expandPath(query); err != nil {
+				return fmt.Errorf("expand path: %w", err)
+			}
+		}
+
+		matches := project.Find(project.NewScanner(cfg).Scan(), query)
+		switch len(matches) {
+		case 0:
+			return fmt.Errorf("project not found: %s (see 'kmux projects scan')", args[0])
+		case 1:
+		default:
+			var paths []string
+			for _, p := range matches {
+				paths = append(paths, "  "+p.Path)
+			}
+			return fmt.Errorf("project name %s is ambiguous, pass one of these paths:\n%s", args[0], strings.Join(paths, "\n"))
+		}
+
+		p := matches[0]
+		return attachSession(state.NewWithConfig(cfg), manager.AttachOpts{
+			Name:         p.Name,
+			Host:         "local",
+			CWD:          p.Path,
+			Layout:       openLayout,
+			BeforePinned: true,
+		})
+	},
+}
+
+// completeProjectNames completes discovered project names.
+func completeProjectNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, p := range project.NewScanner(cfg).Scan() {
+		if !slices.Contains(names, p.Name) {
+			names = append(names, p.Name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	openCmd.Flags().StringVarP(&openLayout, "layout", "l", "", "create session from layout template")
+	rootCmd.AddCommand(openCmd)
+}
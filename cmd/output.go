@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Global output modes for scripting (editor plugins, shell scripts).
+var (
+	jsonOutput  bool
+	quietOutput bool
+)
+
+// actionResult is the --json output of a command that changed a session.
+type actionResult struct {
+	Action    string `json:"action"` // e.g. "detached", "killed", "split"
+	Session   string `json:"session"`
+	Host      string `json:"host"`
+	WindowIDs []int  `json:"window_ids,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// humanf prints a human-readable status line unless --quiet or --json is set.
+func humanf(format string, a ...any) {
+	if jsonOutput || quietOutput {
+		return
+	}
+	fmt.Printf(format, a...)
+}
+
+// debugf prints a diagnostic line on stderr when KMUX_DEBUG=1, the same
+// switch that traces kitty @ commands.
+func debugf(format string, a ...any) {
+	if os.Getenv("KMUX_DEBUG") != "1" {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "kmux: "+format, a...)
+}
+
+// printJSON writes v to stdout as indented JSON.
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cwel/kmux/internal/config"
+	"github.com/cwel/kmux/internal/project"
+	"github.com/spf13/cobra"
+)
+
+var projectsScanMaxDepth int
+var projectsScanStrict bool
+
+var projectsCmd = &cobra.Command{
+	Use:   "projects",
+	Short: "Manage project directories",
+	Long:  "Manage the directories scanned for projects shown in the TUI ([projects] directories in config).",
+}
+
+var projectsAddCmd = &cobra.Command{
+	Use:   "add <dir>",
+	Short: "Add a directory to scan for projects",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := expandPath(args[0])
+		if err != nil {
+			return fmt.Errorf("expand path: %w", err)
+		}
+		info, err := os.Stat(dir)
+		if err != nil {
+			return fmt.Errorf("project directory: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("not a directory: %s", dir)
+		}
+
+		if err := config.AddProjectDirectory(dir); err != nil {
+			return fmt.Errorf("add project directory: %w", err)
+		}
+		fmt.Printf("Added project directory: %s\n", dir)
+		return nil
+	},
+}
+
+var projectsListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List configured project directories",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if len(cfg.Projects.Directories) == 0 {
+			fmt.Println("No project directories configured (add one with 'kmux projects add <dir>')")
+			return nil
+		}
+
+		for _, dir := range cfg.Projects.Directories {
+			status := "ok"
+			if _, err := os.Stat(config.ExpandPath(dir)); err != nil {
+				status = "missing"
+			}
+			fmt.Printf("%-8s %s\n", status, dir)
+		}
+		return nil
+	},
+}
+
+var projectsScanCmd = &cobra.Command{
+	Use:   "scan",
+	Short: "List projects discovered in configured directories",
+	Long: `List projects discovered in configured directories.
+
+Configured directories that don't exist or can't be read are reported on
+stderr and skipped. With --strict they also make the command fail.
+
+Examples:
+  kmux projects scan                # use [projects] max_depth from config
+  kmux projects scan --max-depth 4  # look deeper just this once
+  kmux projects scan --strict       # fail on missing directories`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if err := overrideMaxDepth(cfg, projectsScanMaxDepth); err != nil {
+			return err
+		}
+
+		scanner := project.NewScanner(cfg)
+		problems := scanner.CheckDirs()
+		for _, err := range problems {
+			fmt.Fprintf(os.Stderr, "warning: project directory %v\n", err)
+		}
+
+		projects := scanner.Scan()
+		if len(projects) == 0 {
+			fmt.Println("No projects found")
+		}
+		for _, p := range projects {
+			fmt.Printf("%-24s %s\n", p.Name, p.Path)
+		}
+		if projectsScanStrict && len(problems) > 0 {
+			return fmt.Errorf("configured project directories can't be scanned (%d)", len(problems))
+		}
+		return nil
+	},
+}
+
+// overrideMaxDepth applies a --max-depth flag to cfg for this invocation;
+// 0 keeps the configured depth.
+func overrideMaxDepth(cfg *config.Config, depth int) error {
+	if depth < 0 {
+		return fmt.Errorf("--max-depth must be positive, got %d", depth)
+	}
+	if depth > 0 {
+		cfg.Projects.MaxDepth = depth
+	}
+	return nil
+}
+
+func init() {
+	projectsScanCmd.Flags().IntVar(&projectsScanMaxDepth, "max-depth", 0, "directory depth to scan (overrides [projects] max_depth)")
+	projectsScanCmd.Flags().BoolVar(&projectsScanStrict, "strict", false, "fail if a configured directory is missing or unreadable")
+	projectsCmd.AddCommand(projectsAddCmd)
+	projectsCmd.AddCommand(projectsListCmd)
+	projectsCmd.AddCommand(projectsScanCmd)
+	rootCmd.AddCommand(projectsCmd)
+}
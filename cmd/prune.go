@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cwel/kmux/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pruneDryRun bool
+	pruneMaxAge time.Duration
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete stale save files",
+	Long: `Delete save files that have not been updated within --max-age and whose
+project directory (the first window's working directory) no longer exists.
+Sessions that still have running zmx sessions or kitty windows are kept.
+
+Examples:
+  kmux prune --dry-run
+  kmux prune --max-age 2160h`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := newState()
+		if err != nil {
+			return err
+		}
+		live, err := liveSessionNames(s)
+		if err != nil {
+			return fmt.Errorf("list running sessions (nothing was pruned): %w", err)
+		}
+		st := s.Store()
+
+		if pruneDryRun {
+			stale, err := st.StaleSessions(pruneMaxAge, live)
+			if err != nil {
+				return fmt.Errorf("find stale sessions: %w", err)
+			}
+			if len(stale) == 0 {
+				fmt.Println("No stale sessions")
+				return nil
+			}
+			for _, s := range stale {
+				fmt.Printf("Would remove %s: %s\n", s.Name, s.Reason)
+			}
+			return nil
+		}
+
+		pruned, err := st.PruneStale(pruneMaxAge, live)
+		for _, s := range pruned {
+			fmt.Printf("Removed %s: %s\n", s.Name, s.Reason)
+		}
+		if err != nil {
+			return fmt.Errorf("prune sessions: %w", err)
+		}
+		if len(pruned) == 0 {
+			fmt.Println("No stale sessions")
+		}
+		return nil
+	},
+}
+
+// liveSessionNames returns the sessions that must not be pruned: local ones
+// with running zmx sessions or kitty windows, and any session with a kitty
+// window on another host.
+func liveSessionNames(s *state.State) (map[string]bool, error) {
+	sessions, err := s.Sessions(false)
+	if err != nil {
+		return nil, err
+	}
+	live := make(map[string]bool)
+	for _, sess := range sessions {
+		live[sess.Name] = true
+	}
+	kittyState, _ := s.KittyClient().GetState()
+	for _, osWin := range kittyState {
+		for _, tab := range osWin.Tabs {
+			for _, win := range tab.Windows {
+				if name := win.UserVars["kmux_session"]; name != "" {
+					live[name] = true
+				}
+			}
+		}
+	}
+	return live, nil
+}
+
+func init() {
+	pruneCmd.Flags().BoolVarP(&pruneDryRun, "dry-run", "n", false, "show what would be removed without deleting")
+	pruneCmd.Flags().DurationVar(&pruneMaxAge, "max-age", 30*24*time.Hour, "only prune save files older than this")
+	rootCmd.AddCommand(pruneCmd)
+}
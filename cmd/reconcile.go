@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cwel/kmux/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+var reconcileDryRun bool
+
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Re-tag windows that lost their kmux user_vars",
+	Long: `Find kitty windows running a zmx attach but missing kmux user_vars
+(e.g. after kitty restored them from a session file) and reapply
+kmux_session, kmux_zmx and kmux_host so kmux sees them again.
+
+Examples:
+  kmux reconcile --dry-run
+  kmux reconcile`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := newState()
+		if err != nil {
+			return err
+		}
+
+		if reconcileDryRun {
+			repairs, err := manager.PlanReconcile(s)
+			if err != nil {
+				return err
+			}
+			for _, r := range repairs {
+				fmt.Printf("Would repair window %d: %s@%s (zmx: %s)\n", r.WindowID, r.Session, r.Host, r.Zmx)
+			}
+			fmt.Printf("%d windows need repair\n", len(repairs))
+			return nil
+		}
+
+		repaired, err := manager.Reconcile(s)
+		for _, r := range repaired {
+			fmt.Printf("Repaired window %d: %s@%s (zmx: %s)\n", r.WindowID, r.Session, r.Host, r.Zmx)
+		}
+		fmt.Printf("Repaired %d windows\n", len(repaired))
+		if err != nil {
+			return fmt.Errorf("reconcile: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	reconcileCmd.Flags().BoolVarP(&reconcileDryRun, "dry-run", "n", false, "show windows that would be repaired without changing them")
+	rootCmd.AddCommand(reconcileCmd)
+}
@@ -3,20 +3,26 @@ package cmd
 import (
 	"fmt"
 
-	"github.com/cwel/kmux/internal/state"
+	"github.com/cwel/kmux/internal/manager"
 	"github.com/cwel/kmux/internal/store"
 	"github.com/spf13/cobra"
 )
 
-var renameHost string
+var (
+	renameHost       string
+	renameNoRelaunch bool
+)
 
 var renameCmd = &cobra.Command{
 	Use:   "rename <old> <new>",
 	Short: "Rename a session",
-	Long: `Rename a session. Updates save files, ownership tracking, and kitty tab titles.
+	Long: `Rename a session. Updates save files, ownership tracking, kitty tab titles,
+the kmux_session user_var on active windows, and zmx session names when
+zmx supports renaming. Steps that fail partway are reported.
 
-By default, renames the session across all hosts. Use --host to only rename on a specific host.`,
-	Args:  cobra.ExactArgs(2),
+By default, renames the session across all hosts. Use --host to only rename on a specific host.
+Use --no-relaunch to leave kmux_session user_vars unchanged until the next detach/reattach.`,
+	Args: cobra.ExactArgs(2),
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		// Only complete the first arg (old name), not the second (new name)
 		if len(args) == 0 {
@@ -25,69 +31,83 @@ By default, renames the session across all hosts. Use --host to only rename on a
 		return nil, cobra.ShellCompDirectiveNoFileComp
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		oldName := args[0]
-		newName := args[1]
-
-		if err := store.ValidateSessionName(oldName); err != nil {
-			return fmt.Errorf("invalid old name: %w", err)
-		}
-		if err := store.ValidateSessionName(newName); err != nil {
-			return fmt.Errorf("invalid new name: %w", err)
-		}
+		return runRename(args[0], args[1], renameHost)
+	},
+}
 
-		s := state.New()
-		st := s.Store()
+// runRename renames a session through manager.RenameSession and reports
+// what changed, including any steps that failed partway.
+func runRename(oldName, newName, host string) error {
+	if err := store.ValidateSessionName(oldName); err != nil {
+		return fmt.Errorf("invalid old name: %w", err)
+	}
+	if err := store.ValidateSessionName(newName); err != nil {
+		return fmt.Errorf("invalid new name: %w", err)
+	}
 
-		// 1. Rename the save file (non-fatal: save file might not exist)
-		st.RenameSession(oldName, newName)
+	s, err := newState()
+	if err != nil {
+		return err
+	}
+	if oldName, host, err = sessionHostArg(oldName, host, s.ConfiguredHosts()); err != nil {
+		return err
+	}
+	report, err := manager.RenameSession(s, oldName, newName, host, manager.RenameOpts{SkipUserVars: renameNoRelaunch})
+	if err != nil {
+		return err
+	}
 
-		// 2. Update ownership mappings (zmx name -> session name)
-		if err := store.RenameSessionOwnership(oldName, newName); err != nil {
-			return fmt.Errorf("update ownership: %w", err)
+	if jsonOutput {
+		out := renameResult{
+			Action:      "renamed",
+			Session:     newName,
+			OldName:     oldName,
+			Host:        host,
+			ZmxRenamed:  report.ZmxRenamed,
+			SaveFile:    report.SaveFile,
+			RenamedTabs: report.RenamedTabs,
 		}
-
-		// 3. Update kitty tab titles for active windows
-		kc := s.KittyClient()
-		kittyState, _ := kc.GetState()
-		renamedTabs := 0
-		for _, osWin := range kittyState {
-			for _, tab := range osWin.Tabs {
-				// Check if any window in this tab belongs to the old session
-				for _, win := range tab.Windows {
-					if win.UserVars["kmux_session"] != oldName {
-						continue
-					}
-					// Filter by host if specified
-					if renameHost != "" {
-						winHost := win.UserVars["kmux_host"]
-						if winHost == "" {
-							winHost = "local"
-						}
-						if winHost != renameHost {
-							continue
-						}
-					}
-					kc.SetTabTitle(win.ID, newName)
-					renamedTabs++
-					break // Only rename once per tab
-				}
-			}
+		for _, f := range report.Failures {
+			out.Failures = append(out.Failures, f.Error())
 		}
-		if renamedTabs > 0 {
-			if renameHost != "" {
-				fmt.Printf("Renamed session: %s -> %s on %s (tab titles updated, user_vars unchanged until detach/reattach)\n", oldName, newName, renameHost)
-			} else {
-				fmt.Printf("Renamed session: %s -> %s (tab titles updated, user_vars unchanged until detach/reattach)\n", oldName, newName)
-			}
-		} else {
-			fmt.Printf("Renamed session: %s -> %s\n", oldName, newName)
+		if err := printJSON(out); err != nil {
+			return err
 		}
+	}
 
-		return nil
-	},
+	detail := "tab titles and user_vars updated"
+	if renameNoRelaunch {
+		detail = "tab titles updated, user_vars unchanged until detach/reattach"
+	}
+	switch {
+	case report.RenamedTabs > 0 && host != "":
+		humanf("Renamed session: %s -> %s on %s (%s)\n", oldName, newName, host, detail)
+	case report.RenamedTabs > 0:
+		humanf("Renamed session: %s -> %s (%s)\n", oldName, newName, detail)
+	default:
+		humanf("Renamed session: %s -> %s\n", oldName, newName)
+	}
+
+	if err := report.Err(); err != nil {
+		return fmt.Errorf("rename incomplete: %w", err)
+	}
+	return nil
+}
+
+// renameResult is the --json output of rename.
+type renameResult struct {
+	Action      string            `json:"action"`
+	Session     string            `json:"session"`
+	OldName     string            `json:"old_name"`
+	Host        string            `json:"host,omitempty"` // empty = all hosts
+	ZmxRenamed  map[string]string `json:"zmx_renamed,omitempty"`
+	SaveFile    bool              `json:"save_file"`
+	RenamedTabs int               `json:"renamed_tabs"`
+	Failures    []string          `json:"failures,omitempty"`
 }
 
 func init() {
 	renameCmd.Flags().StringVarP(&renameHost, "host", "H", "", "only rename on specific host (default: all hosts)")
+	renameCmd.Flags().BoolVar(&renameNoRelaunch, "no-relaunch", false, "don't rewrite kmux_session user_vars on active windows")
 	rootCmd.AddCommand(renameCmd)
 }
@@ -3,20 +3,66 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/cwel/kmux/internal/tui"
 	"github.com/spf13/cobra"
 )
 
+var (
+	kittySocket string
+	hostTimeout time.Duration
+	tuiMaxDepth int
+	tuiHost     string
+)
+
+// tuiCmd is the explicit form of running kmux without a subcommand.
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Open the session picker",
+	Long: `Open the interactive session picker (same as running kmux with no command).
+
+Examples:
+  kmux tui --host work   # only work's sessions; new sessions default to work
+  kmux tui --host local  # skip remote hosts`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTUI()
+	},
+}
+
+// addTUIFlags registers the TUI flags on cmd.
+func addTUIFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&tuiHost, "host", "H", "", "only show sessions from this host (local or SSH alias)")
+	cmd.Flags().IntVar(&tuiMaxDepth, "max-depth", 0, "project scan depth in the TUI (overrides [projects] max_depth)")
+	cmd.Flags().DurationVar(&hostTimeout, "timeout", tui.DefaultHostTimeout, "give up on a remote host's sessions in the TUI after this long")
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "kmux",
 	Short: "Session management for kitty + zmx",
-	Long:  "kmux provides tmux-like session persistence using kitty for window management and zmx for process persistence.",
+	Long: `kmux provides tmux-like session persistence using kitty for window management and zmx for process persistence.
+
+With several kitty instances running, pick one with --kitty-socket.
+Socket precedence: --kitty-socket > KMUX_KITTY_SOCKET > [kitty] socket in config > KITTY_LISTEN_ON > kitty's default discovery.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// Expose the flag through the environment so every new State sees it
+		if kittySocket != "" {
+			os.Setenv("KMUX_KITTY_SOCKET", kittySocket)
+		}
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runTUI()
 	},
 }
 
 func init() {
+	rootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "print results as JSON")
+	rootCmd.PersistentFlags().BoolVarP(&quietOutput, "quiet", "q", false, "suppress status messages")
+	rootCmd.PersistentFlags().StringVar(&kittySocket, "kitty-socket", "", "kitty socket to use (e.g. unix:/tmp/kitty-1234)")
+	addTUIFlags(rootCmd)
+	addTUIFlags(tuiCmd)
+	rootCmd.AddCommand(tuiCmd)
 	rootCmd.SetHelpFunc(styledHelp)
 	rootCmd.SetHelpCommand(&cobra.Command{
 		Use:    "help",
@@ -2,12 +2,15 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"time"
 
+	"github.com/cwel/kmux/internal/manager"
 	"github.com/cwel/kmux/internal/model"
-	"github.com/cwel/kmux/internal/state"
 	"github.com/cwel/kmux/internal/store"
 	"github.com/spf13/cobra"
 )
@@ -17,20 +20,50 @@ var sessionCmd = &cobra.Command{
 	Short: "Session state operations",
 }
 
+var sessionGetHost string
+
 var sessionGetCmd = &cobra.Command{
 	Use:   "get <name>",
 	Short: "Output session save file as JSON",
-	Args:  cobra.ExactArgs(1),
+	Long: `Output a session's save file as JSON.
+
+With --host, the save file is fetched from the remote host over SSH.
+
+Examples:
+  kmux session get work
+  kmux session get api --host devbox | jq .tabs`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSessionNames,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
 		if err := store.ValidateSessionName(name); err != nil {
 			return err
 		}
 
-		st := store.DefaultStore()
-		session, err := st.LoadSession(name)
+		s, err := newState()
+		if err != nil {
+			return err
+		}
+		name, host, err := sessionHostArg(name, sessionGetHost, s.ConfiguredHosts())
 		if err != nil {
-			return fmt.Errorf("session not found: %s", name)
+			return err
+		}
+
+		var session *model.Session
+		if host == "" || host == "local" {
+			session, err = s.Store().LoadSession(name)
+			if err != nil {
+				return fmt.Errorf("session not found: %s", name)
+			}
+		} else {
+			client, err := remoteKmuxClient(s, host)
+			if err != nil {
+				return err
+			}
+			session, err = client.GetSession(name)
+			if err != nil {
+				return fmt.Errorf("get %s from %s: %w", name, host, err)
+			}
 		}
 
 		data, err := json.Marshal(session)
@@ -85,11 +118,111 @@ var sessionDeleteCmd = &cobra.Command{
 	},
 }
 
+var sessionImportForce bool
+
+var sessionExportCmd = &cobra.Command{
+	Use:   "export [name...]",
+	Short: "Write save files as a JSON bundle to stdout (default: all)",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		st := store.DefaultStore()
+
+		names := args
+		if len(names) == 0 {
+			var err error
+			names, err = st.ListSessions()
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, name := range names {
+			if err := store.ValidateSessionName(name); err != nil {
+				return err
+			}
+		}
+		bundle, err := st.ExportBundle(names)
+		if err != nil {
+			return err
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(bundle)
+	},
+}
+
+var sessionImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import save files from a JSON bundle on stdin",
+	Long:  "Import save files written by 'kmux session export'. Existing sessions are skipped unless --force is given.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("read stdin: %w", err)
+		}
+
+		var bundle store.Bundle
+		if err := json.Unmarshal(data, &bundle); err != nil {
+			return fmt.Errorf("parse bundle: %w", err)
+		}
+		return importBundle(store.DefaultStore(), &bundle, sessionImportForce)
+	},
+}
+
+// importBundle writes a bundle's save files and their zmx ownership.
+// Existing sessions are skipped unless force is set.
+func importBundle(st *store.Store, bundle *store.Bundle, force bool) error {
+	imported := make(map[string]bool)
+	for _, session := range bundle.Sessions {
+		if session == nil {
+			continue
+		}
+		if err := store.ValidateSessionName(session.Name); err != nil {
+			humanf("Skipped %q: %v\n", session.Name, err)
+			continue
+		}
+		if _, err := st.LoadSession(session.Name); err == nil && !force {
+			humanf("Skipped %s: already exists (use --force to overwrite)\n", session.Name)
+			continue
+		}
+		if err := st.SaveSession(session); err != nil {
+			return fmt.Errorf("save %s: %w", session.Name, err)
+		}
+		imported[session.Name] = true
+		humanf("Imported: %s\n", session.Name)
+	}
+
+	// Only carry over ownership for sessions that were actually written
+	ownership := make(map[string]string)
+	for zmxName, sessName := range bundle.Ownership {
+		if imported[sessName] {
+			ownership[zmxName] = sessName
+		}
+	}
+	if err := store.SetZmxOwnership(ownership); err != nil {
+		return fmt.Errorf("update ownership: %w", err)
+	}
+	return nil
+}
+
+var sessionRenameCmd = &cobra.Command{
+	Use:   "rename <old> <new>",
+	Short: "Rename a session on all hosts (same as kmux rename)",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRename(args[0], args[1], "")
+	},
+}
+
 var sessionListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List sessions as JSON",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		s := state.New()
+		s, err := newState()
+		if err != nil {
+			return err
+		}
 
 		// Get local sessions (zmx + save files, no kitty — kitty is the caller's concern)
 		sessions, _ := s.Sessions(true)
@@ -103,10 +236,138 @@ var sessionListCmd = &cobra.Command{
 	},
 }
 
+var sessionTouchCmd = &cobra.Command{
+	Use:   "touch <name>",
+	Short: "Mark a saved session as recently seen",
+	Long: `Set a session's save file timestamp to now, so it sorts first by last
+seen without attaching to it. A running session without a save file is
+left alone.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSessionNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := store.ValidateSessionName(name); err != nil {
+			return err
+		}
+
+		s, err := newState()
+		if err != nil {
+			return err
+		}
+		err = s.Store().TouchSession(name, time.Now())
+		if errors.Is(err, fs.ErrNotExist) && manager.SessionExists(s, name, "local") {
+			humanf("Session %s has no save file yet, nothing to touch\n", name)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("touch session %s: %w", name, err)
+		}
+		return nil
+	},
+}
+
+var sessionDiffHost string
+
+var sessionDiffCmd = &cobra.Command{
+	Use:   "diff <name>",
+	Short: "Show how a running session differs from its save file",
+	Long: `Compare a session's save file with its current kitty windows and list
+added or removed tabs and panes, changed working directories and commands,
+and changed layouts and split sizes. Tabs and panes are matched by position.
+
+Examples:
+  kmux session diff work
+  kmux session diff work --host devbox
+  kmux session diff work --json`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSessionNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := store.ValidateSessionName(name); err != nil {
+			return err
+		}
+
+		s, err := newState()
+		if err != nil {
+			return err
+		}
+		name, host, err := sessionHostArg(name, sessionDiffHost, s.ConfiguredHosts())
+		if err != nil {
+			return err
+		}
+		if host == "" {
+			host = "local"
+		}
+		var saved *model.Session
+		if host == "local" {
+			saved, err = s.Store().LoadSession(name)
+		} else {
+			client, clientErr := remoteKmuxClient(s, host)
+			if clientErr != nil {
+				return clientErr
+			}
+			saved, err = client.GetSession(name)
+		}
+		if err != nil {
+			return fmt.Errorf("load save file for %s: %w", name, err)
+		}
+
+		kittyState, err := s.KittyClient().GetState()
+		if err != nil {
+			return fmt.Errorf("get kitty state: %w", err)
+		}
+		current := manager.DeriveConfiguredSession(s, name, host, kittyState)
+		if len(current.Tabs) == 0 {
+			return fmt.Errorf("session %s has no attached windows", name)
+		}
+
+		changes := model.DiffSessions(saved, current)
+		if jsonOutput {
+			if changes == nil {
+				changes = []model.Change{}
+			}
+			return printJSON(changes)
+		}
+		if len(changes) == 0 {
+			humanf("No changes since %s was saved\n", name)
+			return nil
+		}
+		for _, c := range changes {
+			humanf("%s\n", formatChange(c))
+		}
+		return nil
+	},
+}
+
+// formatChange renders a diff entry as one line, e.g.
+// "~ tab 0 pane 1: cwd /src -> /src/api".
+func formatChange(c model.Change) string {
+	where := fmt.Sprintf("tab %d", c.Tab)
+	if c.Window >= 0 {
+		where += fmt.Sprintf(" pane %d", c.Window)
+	}
+	switch c.Kind {
+	case "tab-added", "window-added":
+		return fmt.Sprintf("+ %s: %s", where, c.New)
+	case "tab-removed", "window-removed":
+		return fmt.Sprintf("- %s: %s", where, c.Old)
+	default:
+		return fmt.Sprintf("~ %s: %s %s -> %s", where, c.Kind, c.Old, c.New)
+	}
+}
+
 func init() {
 	sessionCmd.AddCommand(sessionGetCmd)
 	sessionCmd.AddCommand(sessionSaveCmd)
 	sessionCmd.AddCommand(sessionDeleteCmd)
 	sessionCmd.AddCommand(sessionListCmd)
+	sessionCmd.AddCommand(sessionRenameCmd)
+	sessionCmd.AddCommand(sessionExportCmd)
+	sessionCmd.AddCommand(sessionImportCmd)
+	sessionCmd.AddCommand(sessionDiffCmd)
+	sessionCmd.AddCommand(sessionTouchCmd)
+	sessionGetCmd.Flags().StringVarP(&sessionGetHost, "host", "H", "", "fetch the save file from this host (default: local)")
+	sessionDiffCmd.Flags().StringVarP(&sessionDiffHost, "host", "H", "", "host of the session (default: local)")
+	sessionImportCmd.Flags().BoolVarP(&sessionImportForce, "force", "f", false, "overwrite existing save files")
 	rootCmd.AddCommand(sessionCmd)
 }
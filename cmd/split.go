@@ -4,14 +4,18 @@ import (
 	"fmt"
 
 	"github.com/cwel/kmux/internal/kitty"
-	"github.com/cwel/kmux/internal/state"
-	"github.com/cwel/kmux/internal/zmx"
+	"github.com/cwel/kmux/internal/manager"
 	"github.com/spf13/cobra"
 )
 
 var (
 	splitSession string
+	splitHost    string
 	splitCwd     string
+	splitCount   int
+	splitGrid    bool
+	splitBias    int
+	splitReuse   bool
 )
 
 var splitCmd = &cobra.Command{
@@ -24,14 +28,31 @@ Direction must be 'vertical' (or 'v') for side-by-side, or 'horizontal' (or 'h')
 If run from within a kmux session, creates a zmx-backed persistent split.
 If run outside a kmux session, creates a native kitty split.
 
-Use --session to specify which session to split (for scripting outside sessions).
+Use --session to specify which session to split (for scripting outside sessions),
+and --host if that session runs on a remote host.
+
+Splits of remote sessions attach to zmx on the remote host through kitten ssh
+and start in the directory reported by the focused pane (REMOTE_CWD); --cwd
+only applies to local sessions.
 
 The --cwd flag controls the working directory. Special values:
   current        Use cwd of the current window (default, preserves SSH context)
   last_reported  Use the last cwd reported by shell integration
   oldest         Use cwd of the oldest foreground process
   root           Use cwd of the original process
-  <path>         Use an explicit directory path`,
+  <path>         Use an explicit directory path
+
+Use --count to create several panes at once, and --grid to switch the tab
+to kitty's grid layout afterwards:
+  kmux split v -n 3 --grid   # four panes in a grid
+
+Use --bias to size the new pane as a percentage of the split (1-99):
+  kmux split v --bias 20     # thin sidebar on the right
+
+Use --reuse-empty for keybindings: if the tab already has an idle shell
+pane, it is focused instead of creating another split. zmx-backed panes
+count as idle only when kitty's shell integration reports the shell inside
+at its prompt.`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		direction := args[0]
@@ -46,15 +67,28 @@ The --cwd flag controls the working directory. Special values:
 			return fmt.Errorf("invalid direction: %s (use 'vertical', 'v', 'horizontal', or 'h')", direction)
 		}
 
+		if splitCount < 1 {
+			return fmt.Errorf("--count must be at least 1")
+		}
+		if splitBias != 0 && (splitBias < 1 || splitBias > 99) {
+			return fmt.Errorf("--bias must be between 1 and 99 (got %d)", splitBias)
+		}
+		if splitReuse && splitCount > 1 {
+			return fmt.Errorf("--reuse-empty and --count are mutually exclusive")
+		}
+
 		sessionName := splitSession
 
-		s := state.New()
+		s, err := newState()
+		if err != nil {
+			return err
+		}
 		k := s.KittyClient()
 
 		// Find session/host/cwd from focused window's user_vars
 		// Note: We query kitty state directly instead of using KITTY_WINDOW_ID env
 		// because --copy-env doesn't work on macOS (KERN_PROCARGS2 is empty for shells)
-		var host string
+		host := splitHost
 		var remoteCWD string
 		if sessionName == "" {
 			kittyState, err := k.GetState()
@@ -73,7 +107,9 @@ The --cwd flag controls the working directory. Special values:
 								continue
 							}
 							sessionName = win.UserVars["kmux_session"]
-							host = win.UserVars["kmux_host"]
+							if host == "" {
+								host = win.UserVars["kmux_host"]
+							}
 							remoteCWD = win.UserVars["REMOTE_CWD"]
 							break
 						}
@@ -87,20 +123,48 @@ The --cwd flag controls the working directory. Special values:
 			host = "local"
 		}
 
+		if splitReuse {
+			if win := findEmptySplitPane(k, sessionName); win != nil {
+				if !win.IsActive {
+					if err := k.FocusWindow(win.ID); err != nil {
+						return fmt.Errorf("focus empty pane: %w", err)
+					}
+				}
+				if jsonOutput {
+					return printJSON(actionResult{Action: "reused", Session: sessionName, Host: host, WindowIDs: []int{win.ID}})
+				}
+				humanf("Reusing empty pane (window %d)\n", win.ID)
+				return nil
+			}
+		}
+
+		var created []int // new window IDs, for --json
+
 		// If no session, create a native kitty split (no zmx)
 		if sessionName == "" {
 			opts := kitty.LaunchOpts{
 				Type:     "window",
 				Location: location,
 				CWD:      splitCwd,
+				Bias:     float64(splitBias),
 			}
 
-			windowID, err := k.Launch(opts)
-			if err != nil {
-				return fmt.Errorf("launch split: %w", err)
+			for i := 0; i < splitCount; i++ {
+				windowID, err := k.Launch(opts)
+				if err != nil {
+					return fmt.Errorf("launch split: %w", err)
+				}
+				humanf("Created native %s split (window %d)\n", direction, windowID)
+				created = append(created, windowID)
+			}
+			if splitGrid {
+				if err := k.GotoLayout("grid"); err != nil {
+					return err
+				}
+			}
+			if jsonOutput {
+				return printJSON(actionResult{Action: "split", WindowIDs: created})
 			}
-
-			fmt.Printf("Created native %s split (window %d)\n", direction, windowID)
 			return nil
 		}
 
@@ -119,7 +183,11 @@ The --cwd flag controls the working directory. Special values:
 		for _, osWin := range kittyState {
 			for _, tab := range osWin.Tabs {
 				for _, win := range tab.Windows {
-					if win.UserVars["kmux_session"] == sessionName {
+					winHost := win.UserVars["kmux_host"]
+					if winHost == "" {
+						winHost = "local"
+					}
+					if win.UserVars["kmux_session"] == sessionName && winHost == host {
 						windowCount++
 					}
 				}
@@ -129,50 +197,68 @@ The --cwd flag controls the working directory. Special values:
 		if windowCount == 0 {
 			return fmt.Errorf("no windows found for session: %s", sessionName)
 		}
+		if err := manager.CheckPaneLimit(s, sessionName, windowCount, splitCount); err != nil {
+			return err
+		}
 
-		// Build zmx session name: {session}.0.{window_idx}
-		// For now, assume single-tab sessions (tab index = 0)
-		zmxName := fmt.Sprintf("%s.0.%d", sessionName, windowCount)
-
-		// Get the zmx client for this host and build attach command
 		zmxClient := s.ZmxClientForHost(host)
-		var zmxCmd []string
-		if zmxClient.IsRemote() && remoteCWD != "" {
-			// Use REMOTE_CWD user var (set by shell integration on remote)
-			zmxCmd = zmxClient.AttachCmd(zmxName, zmx.CWDCommand(remoteCWD))
-		} else {
-			zmxCmd = zmxClient.AttachCmd(zmxName)
-		}
+		for i := 0; i < splitCount; i++ {
+			// Build zmx session name: {session}.0.{window_idx}
+			// For now, assume single-tab sessions (tab index = 0)
+			zmxName := fmt.Sprintf("%s.0.%d", sessionName, windowCount+i)
 
-		// Launch the split window with zmx and user_vars
-		vars := map[string]string{
-			"kmux_zmx":     zmxName,
-			"kmux_session": sessionName,
-		}
-		if host != "local" {
-			vars["kmux_host"] = host
+			windowID, err := k.Launch(manager.SplitLaunchOpts(zmxClient, manager.SplitOpts{
+				Session:   sessionName,
+				Host:      host,
+				ZmxName:   zmxName,
+				Location:  location,
+				CWD:       splitCwd,
+				RemoteCWD: remoteCWD,
+				Bias:      splitBias,
+			}))
+			if err != nil {
+				if splitCount > 1 {
+					return fmt.Errorf("launch split %d of %d (%d created): %w", i+1, splitCount, i, err)
+				}
+				return fmt.Errorf("launch split: %w", err)
+			}
+			humanf("Created %s split (window %d)\n", direction, windowID)
+			created = append(created, windowID)
 		}
 
-		opts := kitty.LaunchOpts{
-			Type:     "window",
-			Location: location,
-			CWD:      splitCwd,
-			Cmd:      zmxCmd,
-			Vars:     vars,
+		if splitGrid {
+			if err := k.GotoLayout("grid"); err != nil {
+				return fmt.Errorf("apply grid layout: %w", err)
+			}
 		}
-
-		windowID, err := k.Launch(opts)
-		if err != nil {
-			return fmt.Errorf("launch split: %w", err)
+		if jsonOutput {
+			return printJSON(actionResult{Action: "split", Session: sessionName, Host: host, WindowIDs: created})
 		}
-
-		fmt.Printf("Created %s split (window %d)\n", direction, windowID)
 		return nil
 	},
 }
 
+// findEmptySplitPane returns an idle pane in the active tab (restricted to
+// session's windows when set), or nil if there is none.
+func findEmptySplitPane(k *kitty.Client, session string) *kitty.Window {
+	kittyState, err := k.GetState()
+	if err != nil {
+		return nil
+	}
+	tab := kitty.FindActiveTab(kittyState)
+	if tab == nil {
+		return nil
+	}
+	return manager.FindEmptyPane(tab, session)
+}
+
 func init() {
 	splitCmd.Flags().StringVarP(&splitSession, "session", "s", "", "Session to create split in (default: $KMUX_SESSION)")
+	splitCmd.Flags().StringVarP(&splitHost, "host", "H", "", "Host of the session (default: from the focused window, or local)")
 	splitCmd.Flags().StringVar(&splitCwd, "cwd", "current", "Working directory (current, last_reported, oldest, root, or path)")
+	splitCmd.Flags().IntVarP(&splitCount, "count", "n", 1, "Number of splits to create")
+	splitCmd.Flags().IntVar(&splitBias, "bias", 0, "Size of the new pane as a percentage (1-99, default: equal)")
+	splitCmd.Flags().BoolVar(&splitReuse, "reuse-empty", false, "Focus an idle shell pane in the tab instead of creating a split")
+	splitCmd.Flags().BoolVar(&splitGrid, "grid", false, "Switch the tab to the grid layout afterwards")
 	rootCmd.AddCommand(splitCmd)
 }
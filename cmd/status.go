@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/cwel/kmux/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the session of the current window",
+	Long: `Show which session and host the current kitty window belongs to.
+
+Handy as a shell prompt segment:
+  kmux status               # myproject@local (pane 2 of 3, zmx: myproject.0.1)
+  kmux status --json        # machine-readable`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := newState()
+		if err != nil {
+			return err
+		}
+
+		info, zmxName, host, err := s.GetCurrentSession()
+		if err != nil {
+			return fmt.Errorf("get current session: %w", err)
+		}
+
+		out := currentStatus{}
+		if info != nil {
+			out = currentStatus{
+				InSession: true,
+				Session:   info.Name,
+				Host:      host,
+				Pane:      currentPaneIndex(s, info.Name, host),
+				Panes:     info.Panes,
+				Zmx:       zmxName,
+			}
+		}
+
+		if jsonOutput {
+			return printJSON(out)
+		}
+
+		if !out.InSession {
+			fmt.Println("Not in a kmux session")
+			return nil
+		}
+		fmt.Printf("%s@%s (pane %d of %d, zmx: %s)\n", out.Session, out.Host, out.Pane, out.Panes, out.Zmx)
+		return nil
+	},
+}
+
+type currentStatus struct {
+	InSession bool   `json:"in_session"`
+	Session   string `json:"session,omitempty"`
+	Host      string `json:"host,omitempty"`
+	Pane      int    `json:"pane,omitempty"` // 1-based position among the session's windows
+	Panes     int    `json:"panes,omitempty"`
+	Zmx       string `json:"zmx,omitempty"`
+}
+
+// currentPaneIndex returns the 1-based position of the current window
+// (KITTY_WINDOW_ID) among the session's windows, or 0 if unknown.
+func currentPaneIndex(s *state.State, name, host string) int {
+	windowID, err := strconv.Atoi(os.Getenv("KITTY_WINDOW_ID"))
+	if err != nil {
+		return 0
+	}
+	windows, err := s.GetWindowsForSessionOnHost(name, host)
+	if err != nil {
+		return 0
+	}
+	for i, win := range windows {
+		if win.ID == windowID {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/cwel/kmux/internal/kitty"
+	"github.com/cwel/kmux/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+var swapCmd = &cobra.Command{
+	Use:   "swap <window_id>",
+	Short: "Swap the active pane with another pane",
+	Long: `Swap the focused kitty window with the given window.
+
+The target must be an adjacent pane in the same tab and session.
+The session's save file is updated afterward so the new order is restored.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		targetID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid window id: %s", args[0])
+		}
+
+		s, err := newState()
+		if err != nil {
+			return err
+		}
+		k := s.KittyClient()
+
+		kittyState, err := k.GetState()
+		if err != nil {
+			return fmt.Errorf("get kitty state: %w", err)
+		}
+
+		active := kitty.FindActiveWindow(kittyState)
+		if active == nil {
+			return fmt.Errorf("no active kitty window")
+		}
+		target := kitty.FindWindow(kittyState, targetID)
+		if target == nil {
+			return fmt.Errorf("window %d not found", targetID)
+		}
+
+		sessionName := active.UserVars["kmux_session"]
+		if sessionName == "" {
+			return fmt.Errorf("active window is not part of a kmux session")
+		}
+		if target.UserVars["kmux_session"] != sessionName {
+			return fmt.Errorf("window %d is not in session %s", targetID, sessionName)
+		}
+
+		if err := k.SwapWindows(active.ID, targetID); err != nil {
+			return fmt.Errorf("swap windows: %w", err)
+		}
+
+		// Capture the new ordering in the save file
+		kittyState, err = k.GetState()
+		if err != nil {
+			return fmt.Errorf("get kitty state: %w", err)
+		}
+		host := active.UserVars["kmux_host"]
+		if host == "" {
+			host = "local"
+		}
+		session := manager.DeriveConfiguredSession(s, sessionName, host, kittyState)
+		return saveDerivedSession(s, session, host)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(swapCmd)
+}
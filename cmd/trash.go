@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cwel/kmux/internal/model"
+	"github.com/cwel/kmux/internal/state"
+	"github.com/cwel/kmux/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var trashRestoreForce bool
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "Recover save files removed by 'kmux kill --all'",
+	Long: `Before 'kmux kill --all' kills anything, the local save files are copied
+to the trash. Entries are kept for [sessions] trash_days (default 7).
+
+Restoring brings back the layouts only; the processes that were running
+in the killed panes are gone.
+
+Examples:
+  kmux trash list
+  kmux trash restore 20260301-120000`,
+}
+
+var trashListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List trashed save files, newest first",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := newState()
+		if err != nil {
+			return err
+		}
+		st := s.Store()
+		if _, err := st.PruneTrash(trashRetention(s), time.Now()); err != nil {
+			return err
+		}
+		trash, err := st.ListTrash()
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			return printJSON(trash)
+		}
+		if len(trash) == 0 {
+			humanf("Trash is empty\n")
+			return nil
+		}
+		for _, entry := range trash {
+			humanf("%-20s %s\n", entry.ID, strings.Join(entry.Sessions, ", "))
+		}
+		return nil
+	},
+}
+
+var trashRestoreCmd = &cobra.Command{
+	Use:   "restore <id>",
+	Short: "Restore the save files in a trash entry",
+	Long:  "Restore the save files in a trash entry. Sessions of remote hosts are saved back on their host. Existing sessions are skipped unless --force is given.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		st := store.DefaultStore()
+		bundle, err := st.LoadTrash(args[0])
+		if err != nil {
+			return err
+		}
+
+		local := &store.Bundle{Ownership: bundle.Ownership}
+		var remote []*model.Session
+		for _, session := range bundle.Sessions {
+			if session != nil && session.Host != "" && session.Host != "local" {
+				remote = append(remote, session)
+			} else {
+				local.Sessions = append(local.Sessions, session)
+			}
+		}
+		if err := importBundle(st, local, trashRestoreForce); err != nil {
+			return err
+		}
+		if len(remote) == 0 {
+			return nil
+		}
+
+		s, err := newState()
+		if err != nil {
+			return err
+		}
+		return restoreRemoteSessions(s, remote, trashRestoreForce)
+	},
+}
+
+// restoreRemoteSessions saves trashed sessions back on the host they were
+// killed on. Sessions the host already has are skipped unless force is set.
+func restoreRemoteSessions(s *state.State, sessions []*model.Session, force bool) error {
+	for _, session := range sessions {
+		host := session.Host
+		client, err := remoteKmuxClient(s, host)
+		if err != nil {
+			humanf("Skipped %s: %v\n", session.Name, err)
+			continue
+		}
+		if _, err := client.GetSession(session.Name); err == nil && !force {
+			humanf("Skipped %s: already exists on %s (use --force to overwrite)\n", session.Name, host)
+			continue
+		}
+		// Remote sees itself as local
+		session.Host = "local"
+		if err := client.SaveSession(session); err != nil {
+			return fmt.Errorf("save %s on %s: %w", session.Name, host, err)
+		}
+		humanf("Imported: %s on %s\n", session.Name, host)
+	}
+	return nil
+}
+
+// trashRetention returns how long trashed save files are kept.
+func trashRetention(s *state.State) time.Duration {
+	days := 7
+	if cfg := s.Config(); cfg != nil && cfg.Sessions.TrashDays > 0 {
+		days = cfg.Sessions.TrashDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+func init() {
+	trashRestoreCmd.Flags().BoolVarP(&trashRestoreForce, "force", "f", false, "overwrite existing save files")
+	trashCmd.AddCommand(trashListCmd)
+	trashCmd.AddCommand(trashRestoreCmd)
+	rootCmd.AddCommand(trashCmd)
+}
@@ -2,23 +2,30 @@ package cmd
 
 import (
 	"fmt"
+	"slices"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/cwel/kmux/internal/config"
 	"github.com/cwel/kmux/internal/manager"
 	"github.com/cwel/kmux/internal/state"
 	"github.com/cwel/kmux/internal/tui"
 )
 
 func runTUI() error {
-	cfg, err := config.LoadConfig()
+	cfg, err := loadConfig()
 	if err != nil {
-		return fmt.Errorf("load config: %w", err)
+		return err
+	}
+	if err := overrideMaxDepth(cfg, tuiMaxDepth); err != nil {
+		return err
 	}
 
-	s := state.New()
+	s := state.NewWithConfig(cfg)
+
+	if tuiHost != "" && tuiHost != "local" && !slices.Contains(s.ConfiguredHosts(), tuiHost) {
+		return fmt.Errorf("unknown host: %s (not in [hosts] config)", tuiHost)
+	}
 
-	m := tui.New(s, cfg)
+	m := tui.New(s, cfg).WithHostTimeout(hostTimeout).WithHost(tuiHost)
 	p := tea.NewProgram(m, tea.WithAltScreen())
 
 	finalModel, err := p.Run()
@@ -65,6 +72,17 @@ func runTUI() error {
 			return nil
 		}
 
+		// Pane commands edited in the launch modal replace the template for this launch
+		if layoutFile := result.LaunchLayoutFile(); layoutFile != nil {
+			return attachSession(s, manager.AttachOpts{
+				Name:         name,
+				Host:         host,
+				CWD:          path,
+				LayoutFile:   layoutFile,
+				BeforePinned: true,
+			})
+		}
+
 		// Create session with name, cwd, optional layout, and host
 		return attachSessionWithHost(s, name, path, result.LaunchLayout(), host)
 	case "kill":
@@ -73,7 +91,7 @@ func runTUI() error {
 		if session == "" {
 			return nil
 		}
-		return killSessionWithHost(s, session, host)
+		return killSessionWithHost(s, session, host, false)
 	}
 
 	return nil
@@ -81,41 +99,67 @@ func runTUI() error {
 
 // attachSessionWithHost handles attach logic for TUI with host support
 func attachSessionWithHost(s *state.State, name, cwd, layout, host string) error {
-	result, err := manager.AttachSession(s, manager.AttachOpts{
+	return attachSession(s, manager.AttachOpts{
 		Name:         name,
 		Host:         host,
 		CWD:          cwd,
 		Layout:       layout,
 		BeforePinned: true,
 	})
+}
+
+// attachSession runs manager.AttachSession and prints the result.
+func attachSession(s *state.State, opts manager.AttachOpts) error {
+	result, err := manager.AttachSession(s, opts)
 	if err != nil {
 		return err
 	}
 
+	if jsonOutput {
+		return printJSON(result)
+	}
+
 	// Print result
 	switch result.Action {
 	case "focused":
-		fmt.Printf("Focused existing session: %s\n", result.SessionName)
+		humanf("Focused existing session: %s\n", result.SessionName)
+	case "replaced":
+		if result.Host != "local" {
+			humanf("Rebuilt session from save file: %s@%s\n", result.SessionName, result.Host)
+		} else {
+			humanf("Rebuilt session from save file: %s\n", result.SessionName)
+		}
+	case "detached":
+		if result.Host != "local" {
+			humanf("Started detached session: %s@%s\n", result.SessionName, result.Host)
+		} else {
+			humanf("Started detached session: %s\n", result.SessionName)
+		}
 	default:
 		if result.Host != "local" {
-			fmt.Printf("Attached to session: %s@%s\n", result.SessionName, result.Host)
+			humanf("Attached to session: %s@%s\n", result.SessionName, result.Host)
 		} else {
-			fmt.Printf("Attached to session: %s\n", result.SessionName)
+			humanf("Attached to session: %s\n", result.SessionName)
 		}
 	}
 	return nil
 }
 
-// killSessionWithHost kills a session on a specific host
-func killSessionWithHost(s *state.State, name, host string) error {
-	if err := manager.KillSession(s, manager.KillOpts{Name: name, Host: host}); err != nil {
+// killSessionWithHost kills a session on a specific host. keepSaveFile
+// leaves its save file in place (kill --zmx-only).
+func killSessionWithHost(s *state.State, name, host string, keepSaveFile bool) error {
+	if err := manager.KillSession(s, manager.KillOpts{Name: name, Host: host, KeepSaveFile: keepSaveFile}); err != nil {
 		return err
 	}
 
+	label := name
 	if host != "" && host != "local" {
-		fmt.Printf("Killed: %s@%s\n", name, host)
+		label += "@" + host
+	}
+	if keepSaveFile {
+		humanf("Killed: %s (save file kept)\n", label)
 	} else {
-		fmt.Printf("Killed: %s\n", name)
+		humanf("Killed: %s\n", label)
 	}
 	return nil
 }
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"github.com/cwel/kmux/internal/manager"
+	"github.com/spf13/cobra"
+)
+
+var zoomCmd = &cobra.Command{
+	Use:   "zoom",
+	Short: "Toggle the active tab between its layout and stack",
+	Long: `Zoom the focused pane by switching its tab to kitty's stack layout, or
+restore the layout the tab had before if it is already zoomed.
+
+The previous layout is remembered on the window (the kmux_zoom user var), so
+the toggle works across invocations, like tmux's zoom. Saving or detaching
+a zoomed session records that layout, with its splits, rather than stack.
+
+Examples:
+  kmux zoom`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := newState()
+		if err != nil {
+			return err
+		}
+		layout, err := manager.ToggleZoom(s.KittyClient())
+		if err != nil {
+			return err
+		}
+		humanf("Layout: %s\n", layout)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(zoomCmd)
+}
@@ -1,18 +1,25 @@
 package config
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/pelletier/go-toml/v2"
 )
 
 // KittyConfig holds kitty-specific settings.
 type KittyConfig struct {
-	Socket string `toml:"socket"`
+	Socket        string `toml:"socket"`
+	SocketGlob    string `toml:"socket_glob"`    // pattern searched for sockets when socket isn't found (e.g. "/tmp/mykitty-*")
+	RetryAttempts int    `toml:"retry_attempts"` // attempts for transient kitty @ failures (default 3)
 }
 
 // ProjectsConfig holds project discovery settings.
@@ -25,14 +32,142 @@ type ProjectsConfig struct {
 
 // BrowserConfig holds file browser settings.
 type BrowserConfig struct {
-	StartPath string `toml:"start_path"` // "~", "cwd", or absolute path
+	StartPath   string `toml:"start_path"`   // "~", "cwd", or absolute path
+	Command     string `toml:"command"`      // browser binary (default: "yazi")
+	ChooserFlag string `toml:"chooser_flag"` // prefix for the selection file argument (default: "--chooser-file=")
 }
 
 // HostConfig holds configuration for a remote host.
 // Hosts are referenced by their SSH config alias - all auth/proxy is handled by SSH.
+// SSHTarget and Port connect to a host that has no SSH config entry.
 type HostConfig struct {
-	ZmxPath  string `toml:"zmx_path"`  // optional path to zmx on remote (default: "zmx")
-	KmuxPath string `toml:"kmux_path"` // optional path to kmux on remote (default: "kmux")
+	ZmxPath     string `toml:"zmx_path"`     // optional path to zmx on remote (default: "zmx")
+	KmuxPath    string `toml:"kmux_path"`    // optional path to kmux on remote (default: "kmux")
+	ControlPath string `toml:"control_path"` // ssh ControlPath for connection reuse (default: DefaultControlPath, "none" disables)
+	SSHTarget   string `toml:"ssh_target"`   // optional [user@]host to connect to instead of the alias (IPv6 may be bracketed)
+	Port        int    `toml:"port"`         // optional ssh port (default: from SSH config, usually 22)
+}
+
+// sshTargetPattern allows user@host names and IPv4/IPv6 addresses, nothing
+// a shell or ssh would interpret.
+var sshTargetPattern = regexp.MustCompile(`^([A-Za-z0-9._-]+@)?(\[[0-9A-Fa-f:.]+(%[A-Za-z0-9_.-]+)?\]|[0-9A-Fa-f:.]+(%[A-Za-z0-9_.-]+)?|[A-Za-z0-9._-]+)$`)
+
+// Validate checks the host's ssh target and port.
+func (h *HostConfig) Validate() error {
+	if h.Port < 0 || h.Port > 65535 {
+		return fmt.Errorf("port must be between 1 and 65535 (got %d)", h.Port)
+	}
+	if h.SSHTarget != "" && (strings.HasPrefix(h.SSHTarget, "-") || !sshTargetPattern.MatchString(h.SSHTarget)) {
+		return fmt.Errorf("invalid ssh_target %q (use user@host)", h.SSHTarget)
+	}
+	return nil
+}
+
+// SSHDestination returns the ssh arguments that name the host: the alias,
+// or -p PORT and SSHTarget when set. Brackets around an IPv6 address are
+// dropped, since ssh takes the address bare with the port given by -p.
+// Safe to call on a nil HostConfig.
+func (h *HostConfig) SSHDestination(alias string) []string {
+	if h == nil {
+		return []string{alias}
+	}
+	var args []string
+	if h.Port > 0 {
+		args = append(args, "-p", strconv.Itoa(h.Port))
+	}
+	target := alias
+	if h.SSHTarget != "" {
+		target = h.SSHTarget
+		if user, host, ok := strings.Cut(target, "@"); ok {
+			target = user + "@" + strings.Trim(host, "[]")
+		} else {
+			target = strings.Trim(target, "[]")
+		}
+	}
+	return append(args, target)
+}
+
+// DefaultControlPath is the ssh ControlPath used when a host doesn't set one.
+// %r, %h and %p are expanded by ssh to the remote user, host and port.
+const DefaultControlPath = "/tmp/kmux-ssh-%r@%h:%p"
+
+func (h *HostConfig) controlPath() string {
+	if h != nil && h.ControlPath != "" {
+		return h.ControlPath
+	}
+	return DefaultControlPath
+}
+
+// SSHArgs returns ssh options that run over the host's shared control
+// connection when one is up (see EnsureControlMaster), so repeated remote
+// queries skip the handshake. Without one ssh connects directly; it never
+// becomes the master itself, since a lingering master would hold the
+// caller's output pipes open. Safe to call on a nil HostConfig.
+func (h *HostConfig) SSHArgs() []string {
+	controlPath := h.controlPath()
+	if controlPath == "none" {
+		return nil
+	}
+	return []string{
+		"-o", "ControlMaster=no",
+		"-o", "ControlPath=" + controlPath,
+	}
+}
+
+// controlMasters holds one sync.Once per control path and destination, so
+// each process checks or starts a host's shared connection only once.
+var controlMasters sync.Map
+
+// EnsureControlMaster starts the shared ssh connection SSHArgs reuses,
+// unless one is already up. The master is started on its own (ssh -MNf)
+// with no output captured, so it can stay in the background for
+// ControlPersist without keeping any caller waiting on its pipes.
+// Failures are ignored: commands then connect directly.
+func (h *HostConfig) EnsureControlMaster(ctx context.Context, alias string) {
+	controlPath := h.controlPath()
+	if controlPath == "none" {
+		return
+	}
+	args := append([]string{"-o", "ControlPath=" + controlPath}, h.SSHDestination(alias)...)
+
+	once, _ := controlMasters.LoadOrStore(strings.Join(args, " "), &sync.Once{})
+	once.(*sync.Once).Do(func() {
+		if exec.CommandContext(ctx, "ssh", append([]string{"-O", "check"}, args...)...).Run() == nil {
+			return
+		}
+		exec.CommandContext(ctx, "ssh", append([]string{"-M", "-N", "-f", "-o", "ControlPersist=60s"}, args...)...).Run()
+	})
+}
+
+// RemoteConfig holds settings shared by all remote hosts.
+type RemoteConfig struct {
+	CacheTTL    int `toml:"cache_ttl"`     // seconds to reuse a host's zmx list result (default 5, 0 disables)
+	FocusWaitMS int `toml:"focus_wait_ms"` // max wait for a reattached remote window before focusing it (default 1000, 0 disables)
+}
+
+// SessionsConfig holds session persistence settings.
+type SessionsConfig struct {
+	NoAutosave   []string `toml:"no_autosave"`         // glob patterns of session names never saved on detach
+	CaptureEnv   []string `toml:"capture_env"`         // window env vars saved on detach and replayed on restore
+	NameStrategy string   `toml:"name_strategy"`       // how names are derived from directories: basename, parent-basename, git-root-basename
+	NoRestore    []string `toml:"no_restore_commands"` // glob patterns of programs saved as a plain shell instead of re-run
+	TrashDays    int      `toml:"trash_days"`          // days 'kmux kill --all' snapshots are kept (default 7)
+}
+
+// LimitsConfig holds guardrails against runaway pane creation.
+type LimitsConfig struct {
+	MaxPanesPerSession int `toml:"max_panes_per_session"` // 0 = unlimited
+}
+
+// TUIConfig holds TUI appearance settings.
+// Colors are hex ("#a6e3a1") or ANSI color numbers ("2").
+type TUIConfig struct {
+	ActiveGlyph   string `toml:"active_glyph"` // session with kitty windows (default "●")
+	ActiveColor   string `toml:"active_color"`
+	DetachedGlyph string `toml:"detached_glyph"` // zmx running, no windows (default "◐")
+	DetachedColor string `toml:"detached_color"`
+	SavedGlyph    string `toml:"saved_glyph"` // restore point only (default "○")
+	SavedColor    string `toml:"saved_color"`
 }
 
 // Config holds all kmux configuration.
@@ -40,6 +175,10 @@ type Config struct {
 	Kitty    KittyConfig           `toml:"kitty"`
 	Projects ProjectsConfig        `toml:"projects"`
 	Browser  BrowserConfig         `toml:"browser"`
+	Remote   RemoteConfig          `toml:"remote"`
+	TUI      TUIConfig             `toml:"tui"`
+	Limits   LimitsConfig          `toml:"limits"`
+	Sessions SessionsConfig        `toml:"sessions"`
 	Hosts    map[string]HostConfig `toml:"hosts"` // SSH alias -> host config
 }
 
@@ -53,11 +192,37 @@ func DefaultConfig() *Config {
 			GitOnly:     true, // Only show git repos by default
 		},
 		Browser: BrowserConfig{
-			StartPath: "~", // Start at home directory
+			StartPath:   "~", // Start at home directory
+			Command:     "yazi",
+			ChooserFlag: "--chooser-file=",
+		},
+		Remote: RemoteConfig{
+			CacheTTL:    5,
+			FocusWaitMS: 1000,
+		},
+		TUI: defaultTUIConfig(),
+		Sessions: SessionsConfig{
+			// Only allowlisted keys are ever captured; keep secrets out of this list
+			CaptureEnv: []string{"EDITOR", "VIRTUAL_ENV", "NODE_ENV"},
+			// Pagers and viewers would error or hang when re-run on restore
+			NoRestore: []string{"less", "more", "most", "man", "top", "htop", "btop"},
+			TrashDays: 7,
 		},
 	}
 }
 
+// defaultTUIConfig returns the default status indicators (Catppuccin Mocha).
+func defaultTUIConfig() TUIConfig {
+	return TUIConfig{
+		ActiveGlyph:   "●",
+		ActiveColor:   "#a6e3a1",
+		DetachedGlyph: "◐",
+		DetachedColor: "#a6e3a1",
+		SavedGlyph:    "○",
+		SavedColor:    "#6c7086",
+	}
+}
+
 // LoadConfig loads configuration from the config file, using defaults for missing values.
 func LoadConfig() (*Config, error) {
 	cfg := DefaultConfig()
@@ -79,6 +244,21 @@ func LoadConfig() (*Config, error) {
 	if cfg.Projects.MaxDepth < 1 {
 		cfg.Projects.MaxDepth = 2 // default
 	}
+	if cfg.Remote.CacheTTL < 0 {
+		cfg.Remote.CacheTTL = 0
+	}
+	if cfg.Remote.FocusWaitMS < 0 {
+		cfg.Remote.FocusWaitMS = 0
+	}
+	if cfg.Limits.MaxPanesPerSession < 0 {
+		cfg.Limits.MaxPanesPerSession = 0
+	}
+	for alias, host := range cfg.Hosts {
+		if err := host.Validate(); err != nil {
+			return nil, fmt.Errorf("hosts.%s: %w", alias, err)
+		}
+	}
+	cfg.TUI.fillDefaults()
 
 	return cfg, nil
 }
@@ -123,6 +303,26 @@ func ConfigDir() string {
 	return filepath.Join(configHome, "kmux")
 }
 
+// fillDefaults replaces empty TUI settings with their defaults.
+func (t *TUIConfig) fillDefaults() {
+	def := defaultTUIConfig()
+	for _, f := range []struct {
+		val *string
+		def string
+	}{
+		{&t.ActiveGlyph, def.ActiveGlyph},
+		{&t.ActiveColor, def.ActiveColor},
+		{&t.DetachedGlyph, def.DetachedGlyph},
+		{&t.DetachedColor, def.DetachedColor},
+		{&t.SavedGlyph, def.SavedGlyph},
+		{&t.SavedColor, def.SavedColor},
+	} {
+		if *f.val == "" {
+			*f.val = f.def
+		}
+	}
+}
+
 // SaveConfig writes the config to the config file.
 func SaveConfig(cfg *Config) error {
 	configPath := filepath.Join(ConfigDir(), "config.toml")
@@ -194,6 +394,21 @@ func (c *Config) BrowserStartPath() string {
 	return ExpandPath(path)
 }
 
+// BrowserCommand returns the shell command that runs the file browser and
+// writes the chosen path to chooserFile. The flag is concatenated as-is, so
+// browsers that take a separate argument can use a trailing space (e.g. "-p ").
+func (c *Config) BrowserCommand(chooserFile string) string {
+	command := c.Browser.Command
+	if command == "" {
+		command = "yazi"
+	}
+	flag := c.Browser.ChooserFlag
+	if flag == "" {
+		flag = "--chooser-file="
+	}
+	return command + " " + flag + chooserFile
+}
+
 // HostNames returns a sorted list of configured host aliases.
 func (c *Config) HostNames() []string {
 	if c.Hosts == nil {
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -15,6 +16,19 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.Projects.MaxDepth != 2 {
 		t.Errorf("Projects.MaxDepth = %d, want 2", cfg.Projects.MaxDepth)
 	}
+	if got := cfg.BrowserCommand("/tmp/choice"); got != "yazi --chooser-file=/tmp/choice" {
+		t.Errorf("BrowserCommand() = %q, want yazi default", got)
+	}
+}
+
+func TestBrowserCommandCustom(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Browser.Command = "nnn"
+	cfg.Browser.ChooserFlag = "-p "
+
+	if got := cfg.BrowserCommand("/tmp/choice"); got != "nnn -p /tmp/choice" {
+		t.Errorf("BrowserCommand() = %q, want %q", got, "nnn -p /tmp/choice")
+	}
 }
 
 func TestConfigDir(t *testing.T) {
@@ -123,3 +137,91 @@ max_depth = 4
 		t.Errorf("Kitty.Socket = %q, want empty string (default)", cfg.Kitty.Socket)
 	}
 }
+
+func TestLoadConfigTUIDefaults(t *testing.T) {
+	dir := t.TempDir()
+	content := `
+[tui]
+detached_glyph = "◆"
+saved_color = ""
+`
+	os.WriteFile(filepath.Join(dir, "config.toml"), []byte(content), 0644)
+
+	os.Setenv("KMUX_CONFIG_DIR", dir)
+	defer os.Unsetenv("KMUX_CONFIG_DIR")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	if cfg.TUI.DetachedGlyph != "◆" {
+		t.Errorf("TUI.DetachedGlyph = %q, want %q", cfg.TUI.DetachedGlyph, "◆")
+	}
+	if cfg.TUI.ActiveGlyph != "●" {
+		t.Errorf("TUI.ActiveGlyph = %q, want default", cfg.TUI.ActiveGlyph)
+	}
+	if cfg.TUI.SavedColor != "#6c7086" {
+		t.Errorf("TUI.SavedColor = %q, want default for empty value", cfg.TUI.SavedColor)
+	}
+}
+
+func TestHostSSHArgs(t *testing.T) {
+	var nilHost *HostConfig
+	args := nilHost.SSHArgs()
+	if len(args) != 4 || args[1] != "ControlMaster=no" || args[3] != "ControlPath="+DefaultControlPath {
+		t.Errorf("nil host SSHArgs() = %v, want default control path", args)
+	}
+
+	custom := &HostConfig{ControlPath: "~/.ssh/cm-%h"}
+	if args := custom.SSHArgs(); args[3] != "ControlPath=~/.ssh/cm-%h" {
+		t.Errorf("custom SSHArgs() = %v", args)
+	}
+
+	disabled := &HostConfig{ControlPath: "none"}
+	if args := disabled.SSHArgs(); args != nil {
+		t.Errorf("disabled SSHArgs() = %v, want nil", args)
+	}
+}
+
+func TestHostSSHDestination(t *testing.T) {
+	var nilHost *HostConfig
+	if got := nilHost.SSHDestination("devbox"); !reflect.DeepEqual(got, []string{"devbox"}) {
+		t.Errorf("nil host SSHDestination() = %v, want [devbox]", got)
+	}
+
+	tests := []struct {
+		host HostConfig
+		want []string
+	}{
+		{HostConfig{}, []string{"devbox"}},
+		{HostConfig{Port: 2222}, []string{"-p", "2222", "devbox"}},
+		{HostConfig{SSHTarget: "me@build.example.com"}, []string{"me@build.example.com"}},
+		{HostConfig{SSHTarget: "me@[2001:db8::1]", Port: 2200}, []string{"-p", "2200", "me@2001:db8::1"}},
+		{HostConfig{SSHTarget: "fe80::1%eth0"}, []string{"fe80::1%eth0"}},
+	}
+	for _, tt := range tests {
+		if err := tt.host.Validate(); err != nil {
+			t.Errorf("Validate(%+v) = %v", tt.host, err)
+		}
+		if got := tt.host.SSHDestination("devbox"); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SSHDestination(%+v) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestHostValidate(t *testing.T) {
+	invalid := []HostConfig{
+		{Port: 70000},
+		{Port: -1},
+		{SSHTarget: "host; rm -rf ~"},
+		{SSHTarget: "$(id)@host"},
+		{SSHTarget: "-oProxyCommand=sh"},
+		{SSHTarget: "me@host:22"},
+	}
+	for _, h := range invalid {
+		if err := h.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want error", h)
+		}
+	}
+}
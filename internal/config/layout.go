@@ -16,7 +16,7 @@ type Layout struct {
 // LayoutTab defines a tab within a layout.
 type LayoutTab struct {
 	Title    string   `yaml:"title"`
-	Layout   string   `yaml:"layout"`   // tall, fat, grid, horizontal, vertical
+	Layout   string   `yaml:"layout"`   // tall, fat, grid, horizontal, vertical, stack
 	Bias     int      `yaml:"bias"`     // percentage for main pane (default 50)
 	FullSize int      `yaml:"full_size"` // number of "main" panes (default 1)
 	Panes    []string `yaml:"panes"`    // commands for each pane
@@ -29,6 +29,7 @@ var ValidLayouts = map[string]bool{
 	"grid":       true,
 	"horizontal": true,
 	"vertical":   true,
+	"stack":      true,
 }
 
 // ParseLayout parses a YAML layout definition.
@@ -46,7 +47,7 @@ func (t *LayoutTab) Validate() error {
 		return fmt.Errorf("layout type required")
 	}
 	if !ValidLayouts[t.Layout] {
-		return fmt.Errorf("invalid layout type: %q (valid: tall, fat, grid, horizontal, vertical)", t.Layout)
+		return fmt.Errorf("invalid layout type: %q (valid: tall, fat, grid, horizontal, vertical, stack)", t.Layout)
 	}
 	if len(t.Panes) == 0 {
 		return fmt.Errorf("at least one pane required")
@@ -3,37 +3,81 @@ package kitty
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 )
 
+// DefaultRetryAttempts is how many times transient kitty failures are attempted.
+const DefaultRetryAttempts = 3
+
+// transientErrors are stderr fragments from kitty @ that indicate a temporary
+// failure (e.g. during startup or a config reload) worth retrying. Besides
+// connectErrors, they may come after kitty already ran the command.
+var transientErrors = []string{
+	"connection reset",
+	"broken pipe",
+	"timed out",
+	"resource temporarily unavailable",
+}
+
+// connectErrors are stderr fragments from kitty @ meaning the command never
+// reached kitty (no listener, or the socket doesn't exist yet), so even
+// commands that aren't safe to repeat can be retried.
+var connectErrors = []string{
+	"connection refused",
+	"no such file or directory",
+}
+
 // Client communicates with kitty via `kitty @` commands.
 // On remote hosts (connected via kitten ssh), it falls back to `kitten @`
 // which uses TTY-based DCS escape sequences instead of a unix socket.
 type Client struct {
-	socketPath string // Socket path from config, or empty to use kitty's default discovery
-	useKitten  bool   // Use `kitten @` TTY-based remote control (for kitten ssh remotes)
-	kittenPath string // Path to kitten binary (when useKitten is true)
+	socketPath string    // Socket path from config, or empty to use kitty's default discovery
+	useKitten  bool      // Use `kitten @` TTY-based remote control (for kitten ssh remotes)
+	kittenPath string    // Path to kitten binary (when useKitten is true)
+	attempts   int       // Attempts for retryable commands (0 means DefaultRetryAttempts)
+	trace      io.Writer // Receives each kitty @ command line before it runs (nil: off)
 }
 
 // NewClient creates a new kitty client with no socket path.
 // Use NewClientWithSocket to specify the socket from config.
 func NewClient() *Client {
-	return newClient("")
+	return newClient("", "")
 }
 
 // NewClientWithSocket creates a client with an explicit socket path.
 // The socket is resolved using environment and filesystem checks.
 func NewClientWithSocket(socketPath string) *Client {
-	return newClient(socketPath)
+	return newClient(socketPath, "")
+}
+
+// NewClientWithSocketGlob is NewClientWithSocket that also searches
+// socketGlob (e.g. "/tmp/mykitty-*") when the socket isn't found.
+func NewClientWithSocketGlob(socketPath, socketGlob string) *Client {
+	return newClient(socketPath, socketGlob)
+}
+
+// NewClientWithExplicitSocket creates a client bound to exactly the given
+// socket, skipping environment and filesystem resolution. Used when the user
+// picks a kitty instance explicitly (--kitty-socket, KMUX_KITTY_SOCKET).
+func NewClientWithExplicitSocket(socket string) *Client {
+	return &Client{socketPath: strings.TrimPrefix(socket, "unix:")}
 }
 
 // newClient creates a client, falling back to kitten @ if no valid socket is available
 // and we detect we're on a remote host via kitten ssh.
-func newClient(socketPath string) *Client {
-	resolved := resolveSocket(socketPath)
+func newClient(socketPath, socketGlob string) *Client {
+	resolved := resolveSocket(socketPath, socketGlob)
 
 	// Check if the resolved socket is actually usable
 	if hasValidSocket(resolved) {
@@ -67,32 +111,114 @@ func hasValidSocket(resolved string) bool {
 }
 
 // resolveSocket determines the actual kitty socket path.
-// Priority: KITTY_LISTEN_ON env → config path with KITTY_PID suffix → exact config path.
-func resolveSocket(configured string) string {
-	// 1. KITTY_LISTEN_ON is definitive (set by kitty in child processes)
+// Priority: config path with KITTY_PID suffix → exact config path → KITTY_LISTEN_ON env
+// → sockets matching socketGlob or $XDG_RUNTIME_DIR/kitty*.
+func resolveSocket(configured, socketGlob string) string {
+	if configured != "" {
+		// 1. Kitty appends -<PID> to listen_on paths; construct and verify
+		if kittyPID := os.Getenv("KITTY_PID"); kittyPID != "" {
+			pidPath := configured + "-" + kittyPID
+			if _, err := os.Stat(pidPath); err == nil {
+				return pidPath
+			}
+		}
+
+		// 2. Exact path exists (e.g. macOS --listen-on CLI flag, or another kitty instance)
+		if _, err := os.Stat(configured); err == nil {
+			return configured
+		}
+	}
+
+	// 3. KITTY_LISTEN_ON names the kitty this process runs in
 	if listenOn := os.Getenv("KITTY_LISTEN_ON"); listenOn != "" {
 		return strings.TrimPrefix(listenOn, "unix:")
 	}
 
-	// 2. Kitty appends -<PID> to listen_on paths; construct and verify
-	if kittyPID := os.Getenv("KITTY_PID"); kittyPID != "" {
-		pidPath := configured + "-" + kittyPID
-		if _, err := os.Stat(pidPath); err == nil {
-			return pidPath
+	// 4. Search for a socket, e.g. listen_on with a different prefix
+	if found := discoverSocket(socketGlobs(socketGlob)); found != "" {
+		return found
+	}
+
+	// 5. Fallback to configured path as-is (error will surface from kitty)
+	return configured
+}
+
+// socketGlobs returns the patterns searched for kitty sockets, in order.
+func socketGlobs(configured string) []string {
+	var globs []string
+	if configured != "" {
+		globs = append(globs, configured)
+	}
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		globs = append(globs, filepath.Join(runtimeDir, "kitty*"))
+	}
+	return globs
+}
+
+// discoverSocket returns the first unix socket matching globs, preferring
+// one whose -PID suffix names a running process (kitty appends its PID to
+// listen_on paths, and sockets of crashed instances linger).
+func discoverSocket(globs []string) string {
+	var fallback string
+	for _, pattern := range globs {
+		matches, _ := filepath.Glob(pattern)
+		for _, path := range matches {
+			fi, err := os.Stat(path)
+			if err != nil || fi.Mode()&os.ModeSocket == 0 {
+				continue
+			}
+			if socketPIDRunning(path) {
+				return path
+			}
+			if fallback == "" {
+				fallback = path
+			}
 		}
 	}
+	return fallback
+}
 
-	// 3. Exact path exists (e.g. macOS --listen-on CLI flag)
-	if _, err := os.Stat(configured); err == nil {
-		return configured
+// socketPIDRunning reports whether path ends in -PID of a running process.
+func socketPIDRunning(path string) bool {
+	i := strings.LastIndex(path, "-")
+	if i < 0 {
+		return false
+	}
+	pid, err := strconv.Atoi(path[i+1:])
+	if err != nil || pid <= 0 {
+		return false
 	}
+	err = syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
 
-	// 4. Fallback to configured path as-is (error will surface from kitty)
-	return configured
+// Sentinel errors for kitty @ failures, matched with errors.Is. The wrapped
+// error keeps kitty's stderr for diagnostics.
+var (
+	// ErrNoSuchWindow means the match expression matched no window or tab.
+	ErrNoSuchWindow = errors.New("no such window")
+	// ErrRemoteControlDisabled means kitty refused remote control.
+	ErrRemoteControlDisabled = errors.New("kitty remote control is disabled")
+)
+
+// classifyErr returns the sentinel error for a kitty @ stderr, or nil.
+func classifyErr(stderr string) error {
+	lower := strings.ToLower(stderr)
+	switch {
+	case isNoMatchError(stderr):
+		return ErrNoSuchWindow
+	case strings.Contains(lower, "remote control is disabled"):
+		return ErrRemoteControlDisabled
+	}
+	return nil
 }
 
-// wrapErr adds context-appropriate hints to kitty remote control errors.
+// wrapErr adds context-appropriate hints to kitty remote control errors,
+// wrapping a sentinel error when stderr matches a known failure.
 func (c *Client) wrapErr(subcmd string, err error, stderr string) error {
+	if sentinel := classifyErr(stderr); sentinel != nil {
+		err = fmt.Errorf("%w: %w", sentinel, err)
+	}
 	if c.useKitten {
 		return fmt.Errorf("kitten @ %s: %w: %s\n(hint: ensure allow_remote_control is not 'socket-only' in kitty.conf)", subcmd, err, stderr)
 	}
@@ -119,9 +245,33 @@ func (c *Client) kittyCmd(args ...string) *exec.Cmd {
 	// instead of a remote control client. These get inherited when kmux
 	// is launched via --copy-env from a window running kitten ssh.
 	cmd.Env = sanitizeKittyEnv()
+	if c.trace != nil {
+		fmt.Fprintln(c.trace, "+", ShellJoin(cmd.Args))
+	}
 	return cmd
 }
 
+// SetTrace makes the client print every kitty @ command line, quoted for a
+// shell, to w before running it (e.g. os.Stderr for --print-cmd). nil
+// turns tracing off.
+func (c *Client) SetTrace(w io.Writer) {
+	c.trace = w
+}
+
+// ShellJoin joins args into a line a POSIX shell would split back into
+// the same args, single-quoting those with special characters.
+func ShellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg != "" && strings.Trim(arg, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789@%+=:,./_-") == "" {
+			quoted[i] = arg
+			continue
+		}
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
+
 // sanitizeKittyEnv returns the current environment with kitten ssh askpass
 // variables removed. When kitty @ delegates to kitten (kitty 0.45+), these
 // vars cause kitten to act as an SSH askpass helper instead of processing
@@ -144,6 +294,67 @@ func sanitizeKittyEnv() []string {
 	return env
 }
 
+// SetRetryAttempts sets how many times Launch, FocusWindow, and GetState are
+// attempted when kitty reports a transient error (for Launch, only when the
+// command never reached kitty). Values < 1 restore the default.
+func (c *Client) SetRetryAttempts(n int) {
+	c.attempts = n
+}
+
+// isTransientError reports whether a failed kitty @ command is worth retrying.
+// Only exit errors with a known transient stderr are retried, so genuine
+// failures like "no matching windows" surface immediately.
+func isTransientError(err error, stderr string) bool {
+	return stderrMatches(err, stderr, transientErrors) || isConnectError(err, stderr)
+}
+
+// isConnectError reports whether a failed kitty @ command never reached
+// kitty, which makes it safe to retry commands with side effects.
+func isConnectError(err error, stderr string) bool {
+	return stderrMatches(err, stderr, connectErrors)
+}
+
+// stderrMatches reports whether err is an exit error whose stderr contains
+// one of patterns.
+func stderrMatches(err error, stderr string, patterns []string) bool {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return false
+	}
+	stderr = strings.ToLower(stderr)
+	for _, pattern := range patterns {
+		if strings.Contains(stderr, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// runWithRetry runs a kitty @ command, retrying failures for which retryable
+// returns true with exponential backoff (100ms, 200ms, 400ms, ...).
+func (c *Client) runWithRetry(retryable func(err error, stderr string) bool, args ...string) (stdout, stderr bytes.Buffer, err error) {
+	attempts := c.attempts
+	if attempts < 1 {
+		attempts = DefaultRetryAttempts
+	}
+
+	backoff := 100 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		stdout.Reset()
+		stderr.Reset()
+		cmd := c.kittyCmd(args...)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+
+		err = cmd.Run()
+		if err == nil || attempt >= attempts || !retryable(err, stderr.String()) {
+			return stdout, stderr, err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
 // ParseState parses JSON output from `kitty @ ls`.
 func ParseState(data []byte) (KittyState, error) {
 	var state KittyState
@@ -155,18 +366,50 @@ func ParseState(data []byte) (KittyState, error) {
 
 // GetState retrieves the current kitty state.
 func (c *Client) GetState() (KittyState, error) {
-	cmd := c.kittyCmd("ls")
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
+	stdout, stderr, err := c.runWithRetry(isTransientError, "ls")
+	if err != nil {
 		return nil, c.wrapErr("ls", err, stderr.String())
 	}
 
 	return ParseState(stdout.Bytes())
 }
 
+// GetStateMatching retrieves kitty state limited to windows matching a kitty
+// match expression (e.g. "id:12" or "var:kmux_session=^work$"), which is
+// much cheaper than GetState on large setups. No matches is an empty state.
+func (c *Client) GetStateMatching(match string) (KittyState, error) {
+	stdout, stderr, err := c.runWithRetry(isTransientError, "ls", "--match", match)
+	if err != nil {
+		err = c.wrapErr("ls", err, stderr.String())
+		if errors.Is(err, ErrNoSuchWindow) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return ParseState(stdout.Bytes())
+}
+
+// isNoMatchError reports whether kitty @ failed only because nothing matched.
+func isNoMatchError(stderr string) bool {
+	return strings.Contains(strings.ToLower(stderr), "no matching")
+}
+
+// GetWindow retrieves a single window by ID, or nil if it doesn't exist.
+func (c *Client) GetWindow(id int) (*Window, error) {
+	state, err := c.GetStateMatching("id:" + strconv.Itoa(id))
+	if err != nil {
+		return nil, err
+	}
+	return FindWindow(state, id), nil
+}
+
+// MatchUserVar returns a kitty match expression for windows whose user var
+// key equals value exactly (kitty treats the value as a regex).
+func MatchUserVar(key, value string) string {
+	return "var:" + key + "=^" + regexp.QuoteMeta(value) + "$"
+}
+
 // Launch creates a new window/tab in kitty.
 func (c *Client) Launch(opts LaunchOpts) (int, error) {
 	args := []string{"launch"}
@@ -183,8 +426,12 @@ func (c *Client) Launch(opts LaunchOpts) (int, error) {
 	if opts.Location != "" {
 		args = append(args, "--location", opts.Location)
 	}
+	if opts.NextTo != 0 {
+		args = append(args, "--next-to", fmt.Sprintf("id:%d", opts.NextTo))
+	}
 	if opts.Bias > 0 {
-		args = append(args, "--bias", fmt.Sprintf("%d", opts.Bias))
+		// Bias is carried as a float through restore and rounded only here
+		args = append(args, "--bias", fmt.Sprintf("%d", int(math.Round(opts.Bias))))
 	}
 	// Add environment variables
 	for key, val := range opts.Env {
@@ -198,12 +445,10 @@ func (c *Client) Launch(opts LaunchOpts) (int, error) {
 		args = append(args, opts.Cmd...)
 	}
 
-	cmd := c.kittyCmd(args...)
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
+	// A launch that reached kitty may have created the window even if the
+	// reply was lost, so only retry when it never got there
+	stdout, stderr, err := c.runWithRetry(isConnectError, args...)
+	if err != nil {
 		return 0, c.wrapErr("launch", err, stderr.String())
 	}
 
@@ -224,16 +469,14 @@ type LaunchOpts struct {
 	Cmd      []string
 	Env      map[string]string // Environment variables to pass to launched window
 	Vars     map[string]string // User variables to set on the window (kitty --var)
-	Bias     int               // 0-100 percentage for split bias (0 means default/equal)
+	Bias     float64           // 0-100 percentage for split bias (0 means default/equal)
+	NextTo   int               // Window to place the new one next to (and split), instead of the active one
 }
 
 // FocusWindow focuses a window by ID.
 func (c *Client) FocusWindow(id int) error {
-	cmd := c.kittyCmd("focus-window", "--match", fmt.Sprintf("id:%d", id))
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
+	_, stderr, err := c.runWithRetry(isTransientError, "focus-window", "--match", fmt.Sprintf("id:%d", id))
+	if err != nil {
 		return c.wrapErr("focus-window", err, stderr.String())
 	}
 	return nil
@@ -287,6 +530,30 @@ func (c *Client) SetTabTitle(windowID int, title string) error {
 	return nil
 }
 
+// SetWindowTitle sets the title of a window by ID.
+func (c *Client) SetWindowTitle(id int, title string) error {
+	cmd := c.kittyCmd("set-window-title", "--match", fmt.Sprintf("id:%d", id), title)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return c.wrapErr("set-window-title", err, stderr.String())
+	}
+	return nil
+}
+
+// SetUserVar sets a user variable on a window by ID.
+func (c *Client) SetUserVar(id int, key, val string) error {
+	cmd := c.kittyCmd("set-user-vars", "--match", fmt.Sprintf("id:%d", id), key+"="+val)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return c.wrapErr("set-user-vars", err, stderr.String())
+	}
+	return nil
+}
+
 // FocusTab focuses a tab by matching a window ID in that tab.
 func (c *Client) FocusTab(windowID int) error {
 	cmd := c.kittyCmd("focus-tab", "--match", fmt.Sprintf("id:%d", windowID))
@@ -299,13 +566,108 @@ func (c *Client) FocusTab(windowID int) error {
 	return nil
 }
 
-// FindFirstPinnedWindow returns the first window with PINNED user_var set.
-// Returns nil if no pinned windows found.
-func FindFirstPinnedWindow(state KittyState) *Window {
+// moveDirections maps kitty neighbor keys to move_window directions.
+var moveDirections = map[string]string{
+	"left":   "left",
+	"right":  "right",
+	"top":    "up",
+	"bottom": "down",
+}
+
+// SwapWindows swaps window a with window b. The windows must be adjacent
+// in the same tab; a is moved in the direction of b.
+func (c *Client) SwapWindows(a, b int) error {
+	state, err := c.GetState()
+	if err != nil {
+		return err
+	}
+	win := FindWindow(state, a)
+	if win == nil {
+		return fmt.Errorf("window %d not found", a)
+	}
+
+	direction := ""
+	for key, ids := range win.Neighbors {
+		for _, id := range ids {
+			if id == b {
+				direction = moveDirections[key]
+			}
+		}
+	}
+	if direction == "" {
+		return fmt.Errorf("window %d is not adjacent to window %d", b, a)
+	}
+
+	cmd := c.kittyCmd("action", "--match", fmt.Sprintf("id:%d", a), "move_window", direction)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return c.wrapErr("action", err, stderr.String())
+	}
+	return nil
+}
+
+// MoveWindowToTab moves a window into the tab containing targetWindowID,
+// or into a new tab of the same OS window when targetWindowID is 0.
+// The window keeps its process and user vars.
+func (c *Client) MoveWindowToTab(windowID, targetWindowID int) error {
+	target := "new"
+	if targetWindowID != 0 {
+		target = fmt.Sprintf("window_id:%d", targetWindowID)
+	}
+	cmd := c.kittyCmd("detach-window", "--match", fmt.Sprintf("id:%d", windowID), "--target-tab", target)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return c.wrapErr("detach-window", err, stderr.String())
+	}
+	return nil
+}
+
+// SendText sends text to a window by ID as if it were typed.
+// The text is passed on stdin so it is delivered verbatim.
+func (c *Client) SendText(windowID int, text string) error {
+	cmd := c.kittyCmd("send-text", "--match", fmt.Sprintf("id:%d", windowID), "--stdin")
+	cmd.Stdin = strings.NewReader(text)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return c.wrapErr("send-text", err, stderr.String())
+	}
+	return nil
+}
+
+// FindActiveTab returns the active tab of the active OS window, or nil.
+func FindActiveTab(state KittyState) *Tab {
 	for _, osWin := range state {
+		if !osWin.IsActive {
+			continue
+		}
+		for i := range osWin.Tabs {
+			if osWin.Tabs[i].IsActive {
+				return &osWin.Tabs[i]
+			}
+		}
+	}
+	return nil
+}
+
+// FindActiveWindow returns the focused window (active window of the active tab
+// of the active OS window). Returns nil if none is active.
+func FindActiveWindow(state KittyState) *Window {
+	for _, osWin := range state {
+		if !osWin.IsActive {
+			continue
+		}
 		for _, tab := range osWin.Tabs {
+			if !tab.IsActive {
+				continue
+			}
 			for i := range tab.Windows {
-				if tab.Windows[i].UserVars["PINNED"] != "" {
+				if tab.Windows[i].IsActive {
 					return &tab.Windows[i]
 				}
 			}
@@ -314,3 +676,16 @@ func FindFirstPinnedWindow(state KittyState) *Window {
 	return nil
 }
 
+// FindWindow returns the window with the given ID, or nil if not found.
+func FindWindow(state KittyState, id int) *Window {
+	for _, osWin := range state {
+		for _, tab := range osWin.Tabs {
+			for i := range tab.Windows {
+				if tab.Windows[i].ID == id {
+					return &tab.Windows[i]
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -1,9 +1,173 @@
 package kitty
 
 import (
+	"bytes"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
 	"testing"
 )
 
+func TestIsTransientError(t *testing.T) {
+	// A real exit error to exercise the *exec.ExitError check
+	exitErr := exec.Command("sh", "-c", "exit 1").Run()
+
+	tests := []struct {
+		name   string
+		err    error
+		stderr string
+		want   bool
+	}{
+		{"connection refused", exitErr, "Failed to connect: Connection refused", true},
+		{"timeout", exitErr, "Timed out waiting for response from kitty", true},
+		{"no such window", exitErr, "No matching windows for expression: id:42", false},
+		{"not an exit error", errors.New("exec: kitty: not found"), "connection refused", false},
+	}
+
+	for _, tt := range tests {
+		if got := isTransientError(tt.err, tt.stderr); got != tt.want {
+			t.Errorf("%s: isTransientError() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsConnectError(t *testing.T) {
+	exitErr := exec.Command("sh", "-c", "exit 1").Run()
+
+	tests := []struct {
+		name   string
+		stderr string
+		want   bool
+	}{
+		{"connection refused", "Failed to connect: Connection refused", true},
+		{"socket missing", "Failed to connect to unix:/tmp/kitty: No such file or directory", true},
+		// kitty may have launched the window before the reply was lost
+		{"timeout", "Timed out waiting for response from kitty", false},
+		{"connection reset", "Connection reset by peer", false},
+	}
+
+	for _, tt := range tests {
+		if got := isConnectError(exitErr, tt.stderr); got != tt.want {
+			t.Errorf("%s: isConnectError() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNewClientWithExplicitSocket(t *testing.T) {
+	t.Setenv("KITTY_LISTEN_ON", "unix:/tmp/other-kitty")
+
+	c := NewClientWithExplicitSocket("unix:/tmp/kitty-1234")
+	if c.socketPath != "/tmp/kitty-1234" {
+		t.Errorf("socketPath = %q, want %q", c.socketPath, "/tmp/kitty-1234")
+	}
+	if c.useKitten {
+		t.Error("explicit socket should not fall back to kitten")
+	}
+}
+
+// listenUnix creates a unix socket at path, closed when the test ends.
+func listenUnix(t *testing.T, path string) {
+	t.Helper()
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+}
+
+func TestResolveSocketDiscovery(t *testing.T) {
+	t.Setenv("KITTY_LISTEN_ON", "")
+	t.Setenv("KITTY_PID", "")
+	dir := t.TempDir()
+	runtimeDir := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", runtimeDir)
+
+	stale := filepath.Join(dir, "kt-999999999")
+	live := filepath.Join(dir, "kt-"+strconv.Itoa(os.Getpid()))
+	listenUnix(t, stale)
+	if err := os.WriteFile(filepath.Join(dir, "kt-notes"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only a stale socket: used as a fallback
+	glob := filepath.Join(dir, "kt-*")
+	if got := resolveSocket("/nonexistent/kitty", glob); got != stale {
+		t.Errorf("resolveSocket() = %q, want stale socket %q", got, stale)
+	}
+
+	// A socket of a running process wins over the stale one
+	listenUnix(t, live)
+	if got := resolveSocket("/nonexistent/kitty", glob); got != live {
+		t.Errorf("resolveSocket() = %q, want live socket %q", got, live)
+	}
+
+	// XDG_RUNTIME_DIR is searched without a configured glob
+	xdg := filepath.Join(runtimeDir, "kitty-"+strconv.Itoa(os.Getpid()))
+	listenUnix(t, xdg)
+	if got := resolveSocket("", ""); got != xdg {
+		t.Errorf("resolveSocket() = %q, want %q", got, xdg)
+	}
+
+	// KITTY_LISTEN_ON takes precedence over discovery
+	t.Setenv("KITTY_LISTEN_ON", "unix:/tmp/from-env")
+	if got := resolveSocket("", glob); got != "/tmp/from-env" {
+		t.Errorf("resolveSocket() = %q, want KITTY_LISTEN_ON", got)
+	}
+
+	// but not over a configured socket that exists (another kitty instance)
+	configured := filepath.Join(dir, "other-kitty")
+	listenUnix(t, configured)
+	if got := resolveSocket(configured, glob); got != configured {
+		t.Errorf("resolveSocket() = %q, want configured socket %q", got, configured)
+	}
+}
+
+func TestTrace(t *testing.T) {
+	var buf bytes.Buffer
+	c := &Client{socketPath: "/tmp/kitty-1"}
+	c.SetTrace(&buf)
+
+	c.kittyCmd("launch", "--var", "kmux_session=work", "kitten", "ssh", "-t", "devbox", `zmx attach work.0.0 sh -ic "it's"`)
+	want := `+ kitty @ --to unix:/tmp/kitty-1 launch --var kmux_session=work kitten ssh -t devbox 'zmx attach work.0.0 sh -ic "it'\''s"'` + "\n"
+	if buf.String() != want {
+		t.Errorf("trace = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestShellJoin(t *testing.T) {
+	got := ShellJoin([]string{"zmx", "attach", "", "a b", "$HOME"})
+	if want := `zmx attach '' 'a b' '$HOME'`; got != want {
+		t.Errorf("ShellJoin() = %q, want %q", got, want)
+	}
+}
+
+func TestFindActiveWindow(t *testing.T) {
+	state := KittyState{
+		{ID: 1, Tabs: []Tab{{ID: 1, IsActive: true, Windows: []Window{{ID: 1, IsActive: true}}}}},
+		{ID: 2, IsActive: true, Tabs: []Tab{
+			{ID: 2, Windows: []Window{{ID: 2, IsActive: true}}},
+			{ID: 3, IsActive: true, Windows: []Window{{ID: 3}, {ID: 4, IsActive: true}}},
+		}},
+	}
+
+	win := FindActiveWindow(state)
+	if win == nil || win.ID != 4 {
+		t.Fatalf("FindActiveWindow() = %v, want window 4", win)
+	}
+	if tab := FindActiveTab(state); tab == nil || tab.ID != 3 {
+		t.Errorf("FindActiveTab() = %v, want tab 3", tab)
+	}
+	if FindWindow(state, 3) == nil {
+		t.Error("FindWindow(3) = nil, want window 3")
+	}
+	if FindWindow(state, 99) != nil {
+		t.Error("FindWindow(99) should be nil")
+	}
+}
+
 func TestParseState(t *testing.T) {
 	// Sample kitty @ ls output (simplified)
 	jsonData := `[{
@@ -132,3 +296,44 @@ func TestParseState_WithSplits(t *testing.T) {
 		t.Error("nested split should be horizontal=false (hsplit)")
 	}
 }
+
+func TestMatchUserVar(t *testing.T) {
+	got := MatchUserVar("kmux_session", "my.app")
+	want := `var:kmux_session=^my\.app$`
+	if got != want {
+		t.Errorf("MatchUserVar() = %q, want %q", got, want)
+	}
+}
+
+func TestWrapErrSentinels(t *testing.T) {
+	c := &Client{}
+	exitErr := exec.Command("sh", "-c", "exit 1").Run()
+
+	err := c.wrapErr("close-window", exitErr, "Error: No matching windows for expression: id:42")
+	if !errors.Is(err, ErrNoSuchWindow) {
+		t.Errorf("wrapErr() = %v, want ErrNoSuchWindow", err)
+	}
+	var ee *exec.ExitError
+	if !errors.As(err, &ee) {
+		t.Errorf("wrapErr() = %v, want the exit error kept", err)
+	}
+
+	err = c.wrapErr("ls", exitErr, "Error: Remote control is disabled. Add allow_remote_control to your kitty.conf")
+	if !errors.Is(err, ErrRemoteControlDisabled) {
+		t.Errorf("wrapErr() = %v, want ErrRemoteControlDisabled", err)
+	}
+
+	err = c.wrapErr("ls", exitErr, "something else")
+	if errors.Is(err, ErrNoSuchWindow) || errors.Is(err, ErrRemoteControlDisabled) {
+		t.Errorf("wrapErr() = %v, want no sentinel", err)
+	}
+}
+
+func TestIsNoMatchError(t *testing.T) {
+	if !isNoMatchError("Error: No matching windows for expression: id:42") {
+		t.Error("expected no-match error to be recognized")
+	}
+	if isNoMatchError("Error: could not connect to socket") {
+		t.Error("connection error is not a no-match error")
+	}
+}
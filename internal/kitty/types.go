@@ -119,6 +119,20 @@ func (p *Pair) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// MarshalJSON writes a pair in the form UnmarshalJSON reads: a leaf as its
+// group ID, a branch with every field spelled out.
+func (p Pair) MarshalJSON() ([]byte, error) {
+	if p.GroupID != nil {
+		return json.Marshal(*p.GroupID)
+	}
+	return json.Marshal(struct {
+		Horizontal bool    `json:"horizontal"`
+		Bias       float64 `json:"bias"`
+		One        *Pair   `json:"one,omitempty"`
+		Two        *Pair   `json:"two,omitempty"`
+	}{p.Horizontal, p.Bias, p.One, p.Two})
+}
+
 // Window represents a kitty window (pane).
 type Window struct {
 	ID                  int                 `json:"id"`
@@ -131,6 +145,7 @@ type Window struct {
 	Env                 map[string]string   `json:"env"`
 	UserVars            map[string]string   `json:"user_vars"`
 	ForegroundProcesses []ForegroundProcess `json:"foreground_processes"`
+	AtPrompt            bool                `json:"at_prompt"`
 	Neighbors           map[string][]int    `json:"neighbors"`
 }
 
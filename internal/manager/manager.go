@@ -1,18 +1,24 @@
 package manager
 
 import (
+	"encoding/json"
+	"fmt"
+	"math"
 	"strings"
 	"time"
 
 	"github.com/cwel/kmux/internal/config"
 	"github.com/cwel/kmux/internal/kitty"
 	"github.com/cwel/kmux/internal/model"
+	"github.com/cwel/kmux/internal/zmx"
 )
 
 // DeriveSession creates a Session from current kitty state.
 // Uses kitty window user_vars as source of truth for session membership and zmx names.
 // The host parameter filters windows - only windows with matching kmux_host are included.
-func DeriveSession(name, host string, state kitty.KittyState) *model.Session {
+// Environment variables named in envKeys are captured from each window
+// (e.g. the [sessions] capture_env allowlist); nothing else is recorded.
+func DeriveSession(name, host string, state kitty.KittyState, envKeys ...string) *model.Session {
 	if host == "" {
 		host = "local"
 	}
@@ -23,78 +29,114 @@ func DeriveSession(name, host string, state kitty.KittyState) *model.Session {
 		SavedAt: time.Now(),
 	}
 
-	// Use first OS window (typically there's only one)
-	if len(state) == 0 {
-		return session
+	// Walk every OS window; a session's tabs may be spread across several
+	for _, osWin := range state {
+		for i, tab := range osWin.Tabs {
+			if modelTab, ok := deriveTab(name, host, osWin.ID, tab, envKeys); ok {
+				modelTab.Position = i + 1
+				session.Tabs = append(session.Tabs, modelTab)
+			}
+			// Layout template name travels with the windows as a user var
+			for _, win := range tab.Windows {
+				if win.UserVars["kmux_session"] == name && win.UserVars["kmux_layout"] != "" {
+					session.LayoutName = win.UserVars["kmux_layout"]
+				}
+				if win.UserVars["kmux_session"] == name && win.UserVars["kmux_no_save"] == "1" {
+					session.NoSave = true
+				}
+			}
+		}
 	}
-	osWin := state[0]
-
-	for _, tab := range osWin.Tabs {
-		// Build window ID to index map for this tab
-		windowIDToIdx := make(map[int]int)
-		var sessionWindows []model.Window
 
+	// Collect zmx session names for fast reattach (avoids querying zmx list)
+	for _, tab := range session.Tabs {
 		for _, win := range tab.Windows {
-			// Use user_vars as source of truth for session membership
-			if win.UserVars["kmux_session"] != name {
-				continue
-			}
-			// Filter by host
-			winHost := win.UserVars["kmux_host"]
-			if winHost == "" {
-				winHost = "local"
-			}
-			if winHost != host {
-				continue
+			if win.ZmxName != "" {
+				session.ZmxSessions = append(session.ZmxSessions, win.ZmxName)
 			}
-			idx := len(sessionWindows)
-			windowIDToIdx[win.ID] = idx
+		}
+	}
 
-			// Get zmx name from user_vars (source of truth)
-			zmxName := win.UserVars["kmux_zmx"]
+	return session
+}
 
-			sessionWindows = append(sessionWindows, model.Window{
-				CWD:     win.CWD,
-				Command: extractCommand(win),
-				ZmxName: zmxName,
-			})
+// captureEnv returns the entries of env whose keys are in keys, or nil.
+func captureEnv(env map[string]string, keys []string) map[string]string {
+	var captured map[string]string
+	for _, key := range keys {
+		if val, ok := env[key]; ok {
+			if captured == nil {
+				captured = make(map[string]string)
+			}
+			captured[key] = val
 		}
+	}
+	return captured
+}
+
+// deriveTab builds a model.Tab from the windows in tab that belong to the
+// session on host. Returns false if no windows in the tab belong to it.
+func deriveTab(name, host string, osWindowID int, tab kitty.Tab, envKeys []string) (model.Tab, bool) {
+	// A zoomed tab is saved with the layout it goes back to, not stack
+	tab = unzoomedTab(tab)
 
-		if len(sessionWindows) == 0 {
+	// Build window ID to index map for this tab
+	windowIDToIdx := make(map[int]int)
+	var sessionWindows []model.Window
+
+	for _, win := range tab.Windows {
+		// Use user_vars as source of truth for session membership
+		if win.UserVars["kmux_session"] != name {
 			continue
 		}
-
-		modelTab := model.Tab{
-			Title:   tab.Title,
-			Layout:  tab.Layout,
-			Windows: sessionWindows,
+		// Filter by host
+		winHost := win.UserVars["kmux_host"]
+		if winHost == "" {
+			winHost = "local"
 		}
-
-		// Parse split tree if this is a splits layout with multiple windows
-		if tab.Layout == "splits" && len(sessionWindows) > 1 && tab.LayoutState.Pairs != nil {
-			// Build group→window mapping from AllWindows
-			groupToWindowID := tab.LayoutState.AllWindows.GroupToWindowID()
-			if groupToWindowID != nil {
-				splitRoot, err := kitty.PairToSplitNode(tab.LayoutState.Pairs, groupToWindowID, windowIDToIdx)
-				if err == nil {
-					modelTab.SplitRoot = splitRoot
-				}
-			}
+		if winHost != host {
+			continue
 		}
+		idx := len(sessionWindows)
+		windowIDToIdx[win.ID] = idx
 
-		session.Tabs = append(session.Tabs, modelTab)
+		// Get zmx name from user_vars (source of truth)
+		zmxName := win.UserVars["kmux_zmx"]
+		command := extractCommand(win)
+
+		sessionWindows = append(sessionWindows, model.Window{
+			CWD:     win.CWD,
+			Command: command,
+			Title:   command,
+			ZmxName: zmxName,
+			Env:     captureEnv(win.Env, envKeys),
+		})
 	}
 
-	// Collect zmx session names for fast reattach (avoids querying zmx list)
-	for _, tab := range session.Tabs {
-		for _, win := range tab.Windows {
-			if win.ZmxName != "" {
-				session.ZmxSessions = append(session.ZmxSessions, win.ZmxName)
+	if len(sessionWindows) == 0 {
+		return model.Tab{}, false
+	}
+
+	modelTab := model.Tab{
+		Title:      tab.Title,
+		Layout:     tab.Layout,
+		Windows:    sessionWindows,
+		OSWindowID: osWindowID,
+	}
+
+	// Parse split tree if this is a splits layout with multiple windows
+	if tab.Layout == "splits" && len(sessionWindows) > 1 && tab.LayoutState.Pairs != nil {
+		// Build group→window mapping from AllWindows
+		groupToWindowID := tab.LayoutState.AllWindows.GroupToWindowID()
+		if groupToWindowID != nil {
+			splitRoot, err := kitty.PairToSplitNode(tab.LayoutState.Pairs, groupToWindowID, windowIDToIdx)
+			if err == nil {
+				modelTab.SplitRoot = splitRoot
 			}
 		}
 	}
 
-	return session
+	return modelTab, true
 }
 
 // extractCommand gets the foreground command, filtering out infrastructure commands.
@@ -120,6 +162,94 @@ func extractCommand(win kitty.Window) string {
 	return strings.Join(fg.Cmdline, " ")
 }
 
+// FindEmptyPane returns an idle window in tab (see paneIdle). Only windows of
+// session are considered unless session is empty. Windows other than the
+// active one are preferred, so repeated calls from a busy pane land on the
+// idle one.
+func FindEmptyPane(tab *kitty.Tab, session string) *kitty.Window {
+	var active *kitty.Window
+	for i := range tab.Windows {
+		win := &tab.Windows[i]
+		if session != "" && win.UserVars["kmux_session"] != session {
+			continue
+		}
+		if !paneIdle(*win) {
+			continue
+		}
+		if !win.IsActive {
+			return win
+		}
+		active = win
+	}
+	return active
+}
+
+// paneIdle reports whether win sits at a shell prompt. A plain shell pane is
+// idle when extractCommand finds nothing in the foreground. For a zmx-backed
+// pane the foreground process is always zmx (or the kitten/ssh carrying it),
+// which says nothing about the shell inside, so it only counts as idle when
+// kitty's shell integration marks the window as at a prompt.
+func paneIdle(win kitty.Window) bool {
+	if extractCommand(win) != "" {
+		return false
+	}
+	if win.UserVars["kmux_zmx"] != "" || !foregroundIsShell(win) {
+		return win.AtPrompt
+	}
+	return true
+}
+
+// foregroundIsShell reports whether win has no foreground process or a shell.
+func foregroundIsShell(win kitty.Window) bool {
+	if len(win.ForegroundProcesses) == 0 || len(win.ForegroundProcesses[0].Cmdline) == 0 {
+		return true
+	}
+	return isShell(win.ForegroundProcesses[0].Cmdline[0])
+}
+
+// SplitOpts describes a zmx-backed split added to a session.
+type SplitOpts struct {
+	Session   string
+	Host      string // "local" or SSH alias
+	ZmxName   string
+	Location  string // kitty launch location, e.g. vsplit
+	CWD       string // kitty --cwd for local splits (e.g. "current")
+	RemoteCWD string // directory on the remote host (REMOTE_CWD), if known
+	Bias      int    // percentage for the new pane, 0 for equal
+}
+
+// SplitLaunchOpts builds the kitty launch for a split attached to a zmx
+// session. Remote splits run zmx through kitten ssh from the local kitty and
+// start in RemoteCWD; kitty's --cwd is not passed for them, since a local
+// directory is meaningless there and cwd=current on a kitten ssh window
+// would open another ssh connection instead of running the command.
+func SplitLaunchOpts(zmxClient *zmx.Client, opts SplitOpts) kitty.LaunchOpts {
+	vars := map[string]string{
+		"kmux_zmx":     opts.ZmxName,
+		"kmux_session": opts.Session,
+	}
+	launch := kitty.LaunchOpts{
+		Type:     "window",
+		Location: opts.Location,
+		Bias:     float64(opts.Bias),
+		Vars:     vars,
+	}
+
+	if !zmxClient.IsRemote() {
+		launch.CWD = opts.CWD
+		launch.Cmd = zmxClient.AttachCmd(opts.ZmxName)
+		return launch
+	}
+
+	vars["kmux_host"] = opts.Host
+	if opts.RemoteCWD != "" {
+		launch.Cmd = zmxClient.AttachCmd(opts.ZmxName, zmx.CWDCommand(opts.RemoteCWD))
+	} else {
+		launch.Cmd = zmxClient.AttachCmd(opts.ZmxName)
+	}
+	return launch
+}
+
 func isShell(cmd string) bool {
 	shells := []string{"zsh", "bash", "fish", "sh", "/bin/zsh", "/bin/bash", "/bin/fish", "/bin/sh"}
 	for _, s := range shells {
@@ -130,6 +260,138 @@ func isShell(cmd string) bool {
 	return false
 }
 
+// tabAnchor returns the window whose tab a restored session's first tab
+// should be created before, or 0 to let kitty append it. position is the
+// tab's saved 1-based place in the tab bar (0 = unknown). With beforePinned
+// the tab goes no further right than the first pinned tab, and the tab bar
+// considered is the pinned tab's OS window rather than the active one.
+func tabAnchor(state kitty.KittyState, position int, beforePinned bool) int {
+	var tabs []kitty.Tab
+	for _, osWin := range state {
+		if osWin.IsActive {
+			tabs = osWin.Tabs
+		}
+	}
+
+	pinned := -1
+	if beforePinned {
+	findPinned:
+		for _, osWin := range state {
+			for i, tab := range osWin.Tabs {
+				for _, win := range tab.Windows {
+					if win.UserVars["PINNED"] != "" {
+						tabs, pinned = osWin.Tabs, i
+						break findPinned
+					}
+				}
+			}
+		}
+	}
+
+	target := len(tabs)
+	if position > 0 && position-1 < target {
+		target = position - 1
+	}
+	if pinned >= 0 && pinned < target {
+		target = pinned
+	}
+	if target >= len(tabs) || len(tabs[target].Windows) == 0 {
+		return 0
+	}
+	return tabs[target].Windows[0].ID
+}
+
+// ZoomVar is the window user var remembering a tab's layout while kmux zoom
+// has it in kitty's stack layout.
+const ZoomVar = "kmux_zoom"
+
+// ZoomStateVar holds the tab's kitty layout_state (JSON) from before the
+// zoom, next to ZoomVar, so a zoomed splits tab still saves its split tree.
+const ZoomStateVar = "kmux_zoom_state"
+
+// unzoomedTab returns tab as it was before kmux zoom put it in the stack
+// layout, or tab unchanged if it isn't zoomed. Without a recorded layout
+// state, a zoomed splits tab comes back without its split tree.
+func unzoomedTab(tab kitty.Tab) kitty.Tab {
+	layout, winID := zoomedLayout(&tab)
+	if layout == "" {
+		return tab
+	}
+	tab.Layout = layout
+	tab.LayoutState = kitty.LayoutState{}
+	if win := findTabWindow(&tab, winID); win != nil && win.UserVars[ZoomStateVar] != "" {
+		json.Unmarshal([]byte(win.UserVars[ZoomStateVar]), &tab.LayoutState)
+	}
+	return tab
+}
+
+// findTabWindow returns the window with id in tab, or nil.
+func findTabWindow(tab *kitty.Tab, id int) *kitty.Window {
+	for i := range tab.Windows {
+		if tab.Windows[i].ID == id {
+			return &tab.Windows[i]
+		}
+	}
+	return nil
+}
+
+// zoomedLayout returns the layout a tab had before it was zoomed and the
+// window remembering it, or "" if kmux zoom didn't put the tab in stack.
+func zoomedLayout(tab *kitty.Tab) (string, int) {
+	if tab.Layout != "stack" {
+		return "", 0
+	}
+	for _, win := range tab.Windows {
+		if layout := win.UserVars[ZoomVar]; layout != "" {
+			return layout, win.ID
+		}
+	}
+	return "", 0
+}
+
+// ToggleZoom switches the focused tab to kitty's stack layout, showing only
+// the active pane, or back to the layout it had before. The previous layout
+// is kept in ZoomVar on the active window so later invocations can find it,
+// and its layout state in ZoomStateVar so saving a zoomed tab keeps its splits.
+// Returns the layout the tab ends up in.
+func ToggleZoom(k *kitty.Client) (string, error) {
+	state, err := k.GetState()
+	if err != nil {
+		return "", fmt.Errorf("get kitty state: %w", err)
+	}
+	tab := kitty.FindActiveTab(state)
+	win := kitty.FindActiveWindow(state)
+	if tab == nil || win == nil {
+		return "", fmt.Errorf("no active kitty window")
+	}
+
+	if tab.Layout == "stack" {
+		layout, winID := zoomedLayout(tab)
+		if layout == "" {
+			return "", fmt.Errorf("tab uses the stack layout but was not zoomed by kmux")
+		}
+		if err := k.GotoLayout(layout); err != nil {
+			return "", err
+		}
+		if err := k.SetUserVar(winID, ZoomStateVar, ""); err != nil {
+			return "", err
+		}
+		return layout, k.SetUserVar(winID, ZoomVar, "")
+	}
+
+	layoutState, err := json.Marshal(tab.LayoutState)
+	if err != nil {
+		return "", fmt.Errorf("encode layout state: %w", err)
+	}
+	if err := k.SetUserVar(win.ID, ZoomStateVar, string(layoutState)); err != nil {
+		return "", err
+	}
+	if err := k.SetUserVar(win.ID, ZoomVar, tab.Layout); err != nil {
+		return "", err
+	}
+	return "stack", k.GotoLayout("stack")
+}
+
 // LayoutToSession converts a layout template to a session.
 func LayoutToSession(layout *config.Layout, name, cwd string) *model.Session {
 	session := &model.Session{
@@ -142,6 +404,7 @@ func LayoutToSession(layout *config.Layout, name, cwd string) *model.Session {
 		tab := model.Tab{
 			Title:  ltab.Title,
 			Layout: ltab.Layout,
+			Bias:   ltab.Bias,
 		}
 
 		for _, pane := range ltab.Panes {
@@ -157,3 +420,58 @@ func LayoutToSession(layout *config.Layout, name, cwd string) *model.Session {
 
 	return session
 }
+
+// PaneLayout returns a one-tab layout of n shell panes arranged by a simple
+// kitty layout (e.g. grid), for scratch sessions without a template.
+func PaneLayout(n int, kittyLayout string) *config.Layout {
+	return &config.Layout{
+		Name: kittyLayout,
+		Tabs: []config.LayoutTab{{
+			Layout: kittyLayout,
+			Panes:  make([]string, n),
+		}},
+	}
+}
+
+// SessionToLayout converts a session into a layout template named name.
+// Tabs using kitty's splits layout can't be expressed in the template schema,
+// so they degrade to tall (side by side) or fat (stacked) based on the root
+// split; warnings describe every tab that lost structure this way.
+func SessionToLayout(session *model.Session, name string) (*config.Layout, []string) {
+	layout := &config.Layout{
+		Name:        name,
+		Description: fmt.Sprintf("Captured from session %s", session.Name),
+	}
+	var warnings []string
+
+	for i, tab := range session.Tabs {
+		ltab := config.LayoutTab{
+			Title:  tab.Title,
+			Layout: tab.Layout,
+			Bias:   tab.Bias,
+		}
+		for _, win := range tab.Windows {
+			ltab.Panes = append(ltab.Panes, win.Command)
+		}
+
+		if !config.ValidLayouts[tab.Layout] {
+			ltab.Layout = "tall"
+			ltab.Bias = 0
+			if root := tab.SplitRoot; root != nil && !root.IsLeaf() {
+				if !root.Horizontal {
+					ltab.Layout = "fat"
+				}
+				if bias := int(math.Round(root.Bias * 100)); bias != 50 && bias >= 10 && bias <= 90 {
+					ltab.Bias = bias
+				}
+			}
+			if len(tab.Windows) > 2 || (tab.Layout != "splits" && len(tab.Windows) > 1) {
+				warnings = append(warnings, fmt.Sprintf("tab %d (%s): %s layout approximated as %s", i, tab.Title, tab.Layout, ltab.Layout))
+			}
+		}
+
+		layout.Tabs = append(layout.Tabs, ltab)
+	}
+
+	return layout, warnings
+}
@@ -1,9 +1,17 @@
 package manager
 
 import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/cwel/kmux/internal/kitty"
+	"github.com/cwel/kmux/internal/store"
+	"github.com/cwel/kmux/internal/zmx"
 )
 
 func TestDeriveSession(t *testing.T) {
@@ -58,6 +66,71 @@ func TestDeriveSession(t *testing.T) {
 	if session.Tabs[0].Windows[0].Command != "nvim ." {
 		t.Errorf("Window 0 command = %s, want 'nvim .'", session.Tabs[0].Windows[0].Command)
 	}
+
+	// Command doubles as window title; shells leave it empty
+	if session.Tabs[0].Windows[0].Title != "nvim ." {
+		t.Errorf("Window 0 title = %s, want 'nvim .'", session.Tabs[0].Windows[0].Title)
+	}
+	if session.Tabs[0].Windows[1].Title != "" {
+		t.Errorf("Window 1 title = %s, want empty", session.Tabs[0].Windows[1].Title)
+	}
+}
+
+func TestDeriveSession_MultipleOSWindows(t *testing.T) {
+	state := kitty.KittyState{
+		{
+			ID: 1,
+			Tabs: []kitty.Tab{
+				{
+					ID:     1,
+					Title:  "editor",
+					Layout: "splits",
+					Windows: []kitty.Window{
+						{ID: 10, CWD: "/project", UserVars: map[string]string{"kmux_session": "work", "kmux_zmx": "work.0.0"}},
+					},
+				},
+			},
+		},
+		{
+			ID: 2,
+			Tabs: []kitty.Tab{
+				{
+					ID:     2,
+					Title:  "other",
+					Layout: "splits",
+					Windows: []kitty.Window{
+						{ID: 20, CWD: "/elsewhere", UserVars: map[string]string{"kmux_session": "other", "kmux_zmx": "other.0.0"}},
+					},
+				},
+				{
+					ID:     3,
+					Title:  "logs",
+					Layout: "splits",
+					Windows: []kitty.Window{
+						{ID: 30, CWD: "/project/logs", UserVars: map[string]string{"kmux_session": "work", "kmux_zmx": "work.1.0"}},
+					},
+				},
+			},
+		},
+	}
+
+	session := DeriveSession("work", "local", state)
+
+	if len(session.Tabs) != 2 {
+		t.Fatalf("expected 2 tabs across OS windows, got %d", len(session.Tabs))
+	}
+	if session.Tabs[0].Title != "editor" || session.Tabs[1].Title != "logs" {
+		t.Errorf("tab order = [%s %s], want [editor logs]", session.Tabs[0].Title, session.Tabs[1].Title)
+	}
+	if session.Tabs[0].OSWindowID != 1 || session.Tabs[1].OSWindowID != 2 {
+		t.Errorf("OSWindowIDs = [%d %d], want [1 2]", session.Tabs[0].OSWindowID, session.Tabs[1].OSWindowID)
+	}
+	if session.Tabs[0].Position != 1 || session.Tabs[1].Position != 2 {
+		t.Errorf("positions = [%d %d], want [1 2]", session.Tabs[0].Position, session.Tabs[1].Position)
+	}
+	if len(session.ZmxSessions) != 2 {
+		t.Errorf("expected 2 zmx sessions, got %v", session.ZmxSessions)
+	}
 }
 
 func TestDeriveSession_WithSplits(t *testing.T) {
@@ -117,3 +190,347 @@ func TestDeriveSession_WithSplits(t *testing.T) {
 		t.Errorf("bias = %v, want 0.7", tab.SplitRoot.Bias)
 	}
 }
+
+func TestDeriveSession_LayoutName(t *testing.T) {
+	state := kitty.KittyState{
+		{
+			ID: 1,
+			Tabs: []kitty.Tab{
+				{
+					ID:     1,
+					Title:  "dev",
+					Layout: "splits",
+					Windows: []kitty.Window{
+						{ID: 10, CWD: "/project", UserVars: map[string]string{"kmux_session": "work", "kmux_layout": "ide"}},
+						{ID: 11, CWD: "/other", UserVars: map[string]string{"kmux_session": "other", "kmux_layout": "plain"}},
+					},
+				},
+			},
+		},
+	}
+
+	session := DeriveSession("work", "local", state)
+	if session.LayoutName != "ide" {
+		t.Errorf("LayoutName = %q, want %q", session.LayoutName, "ide")
+	}
+}
+
+func TestDeriveSession_NoSave(t *testing.T) {
+	state := kitty.KittyState{
+		{
+			ID: 1,
+			Tabs: []kitty.Tab{
+				{
+					ID:     1,
+					Layout: "splits",
+					Windows: []kitty.Window{
+						{ID: 10, UserVars: map[string]string{"kmux_session": "scratch", "kmux_no_save": "1"}},
+						{ID: 11, UserVars: map[string]string{"kmux_session": "work"}},
+					},
+				},
+			},
+		},
+	}
+
+	if !DeriveSession("scratch", "local", state).NoSave {
+		t.Error("scratch: NoSave = false, want true")
+	}
+	if DeriveSession("work", "local", state).NoSave {
+		t.Error("work: NoSave = true, want false")
+	}
+}
+
+func TestDeriveSession_CaptureEnv(t *testing.T) {
+	state := kitty.KittyState{
+		{
+			ID: 1,
+			Tabs: []kitty.Tab{
+				{
+					ID:     1,
+					Layout: "splits",
+					Windows: []kitty.Window{
+						{
+							ID:       10,
+							UserVars: map[string]string{"kmux_session": "work"},
+							Env:      map[string]string{"EDITOR": "nvim", "AWS_SECRET_ACCESS_KEY": "hunter2"},
+						},
+						{ID: 11, UserVars: map[string]string{"kmux_session": "work"}},
+					},
+				},
+			},
+		},
+	}
+
+	session := DeriveSession("work", "local", state, "EDITOR", "VIRTUAL_ENV")
+	env := session.Tabs[0].Windows[0].Env
+	if len(env) != 1 || env["EDITOR"] != "nvim" {
+		t.Errorf("window 0 env = %v, want only EDITOR", env)
+	}
+	if session.Tabs[0].Windows[1].Env != nil {
+		t.Errorf("window 1 env = %v, want nil", session.Tabs[0].Windows[1].Env)
+	}
+
+	if env := DeriveSession("work", "local", state).Tabs[0].Windows[0].Env; env != nil {
+		t.Errorf("env without allowlist = %v, want nil", env)
+	}
+}
+
+func TestFindEmptyPane(t *testing.T) {
+	shell := []kitty.ForegroundProcess{{Cmdline: []string{"/bin/zsh"}}}
+	nvim := []kitty.ForegroundProcess{{Cmdline: []string{"nvim", "."}}}
+	vars := map[string]string{"kmux_session": "work"}
+
+	tab := &kitty.Tab{Windows: []kitty.Window{
+		{ID: 1, IsActive: true, UserVars: vars, ForegroundProcesses: shell},
+		{ID: 2, UserVars: vars, ForegroundProcesses: nvim},
+		{ID: 3, UserVars: map[string]string{"kmux_session": "other"}, ForegroundProcesses: shell},
+		{ID: 4, UserVars: vars, ForegroundProcesses: shell},
+	}}
+
+	// An idle pane other than the active one wins
+	if win := FindEmptyPane(tab, "work"); win == nil || win.ID != 4 {
+		t.Errorf("FindEmptyPane(work) = %v, want window 4", win)
+	}
+
+	// Only the active pane is idle: it is returned, so nothing new gets created
+	tab.Windows[3].ForegroundProcesses = nvim
+	if win := FindEmptyPane(tab, "work"); win == nil || win.ID != 1 {
+		t.Errorf("FindEmptyPane(work) = %v, want active window 1", win)
+	}
+
+	// Without a session filter any window counts
+	if win := FindEmptyPane(tab, ""); win == nil || win.ID != 3 {
+		t.Errorf("FindEmptyPane(\"\") = %v, want window 3", win)
+	}
+
+	tab.Windows[0].ForegroundProcesses = nvim
+	if win := FindEmptyPane(tab, "work"); win != nil {
+		t.Errorf("FindEmptyPane(work) = %v, want nil when every pane is busy", win)
+	}
+}
+
+func TestFindEmptyPaneZmx(t *testing.T) {
+	zmxAttach := []kitty.ForegroundProcess{{Cmdline: []string{"zmx", "attach", "work.0.0"}}}
+	sshAttach := []kitty.ForegroundProcess{{Cmdline: []string{"kitten", "ssh", "devbox", "zmx", "attach", "work.0.1"}}}
+
+	tab := &kitty.Tab{Windows: []kitty.Window{
+		{ID: 1, IsActive: true, UserVars: map[string]string{"kmux_session": "work", "kmux_zmx": "work.0.0"}, ForegroundProcesses: zmxAttach},
+		{ID: 2, UserVars: map[string]string{"kmux_session": "work", "kmux_zmx": "work.0.1", "kmux_host": "devbox"}, ForegroundProcesses: sshAttach},
+	}}
+
+	// zmx in the foreground says nothing about the shell inside: not idle
+	if win := FindEmptyPane(tab, "work"); win != nil {
+		t.Errorf("FindEmptyPane(work) = %v, want nil when no zmx pane is at a prompt", win)
+	}
+
+	// Shell integration reports the inner shell at its prompt
+	tab.Windows[1].AtPrompt = true
+	if win := FindEmptyPane(tab, "work"); win == nil || win.ID != 2 {
+		t.Errorf("FindEmptyPane(work) = %v, want window 2", win)
+	}
+
+	// A user command inside zmx shows up as the foreground process
+	tab.Windows[1].ForegroundProcesses = []kitty.ForegroundProcess{{Cmdline: []string{"nvim"}}}
+	if win := FindEmptyPane(tab, "work"); win != nil {
+		t.Errorf("FindEmptyPane(work) = %v, want nil when the zmx pane runs a command", win)
+	}
+}
+
+func TestSplitLaunchOpts(t *testing.T) {
+	opts := SplitOpts{
+		Session:   "work",
+		Host:      "devbox",
+		ZmxName:   "work.0.2",
+		Location:  "vsplit",
+		CWD:       "current",
+		RemoteCWD: "/srv/app",
+		Bias:      30,
+	}
+
+	launch := SplitLaunchOpts(zmx.NewRemoteClient("devbox", nil), opts)
+	if launch.CWD != "" {
+		t.Errorf("remote CWD = %q, want none (cwd=current would clone the ssh window)", launch.CWD)
+	}
+	if len(launch.Cmd) != 5 || launch.Cmd[0] != "kitten" || launch.Cmd[1] != "ssh" || launch.Cmd[3] != "devbox" {
+		t.Fatalf("remote Cmd = %q, want kitten ssh -t devbox ...", launch.Cmd)
+	}
+	if remoteCmd := launch.Cmd[4]; !strings.HasPrefix(remoteCmd, "zmx attach work.0.2 ") || !strings.Contains(remoteCmd, "/srv/app") {
+		t.Errorf("remote command = %q, want zmx attach work.0.2 starting in /srv/app", remoteCmd)
+	}
+	if launch.Vars["kmux_host"] != "devbox" || launch.Vars["kmux_session"] != "work" || launch.Vars["kmux_zmx"] != "work.0.2" {
+		t.Errorf("remote Vars = %v", launch.Vars)
+	}
+	if launch.Location != "vsplit" || launch.Bias != 30 {
+		t.Errorf("Location, Bias = %q, %v; want vsplit, 30", launch.Location, launch.Bias)
+	}
+
+	opts.Host = "local"
+	launch = SplitLaunchOpts(zmx.NewClient(), opts)
+	if launch.CWD != "current" {
+		t.Errorf("local CWD = %q, want current", launch.CWD)
+	}
+	if len(launch.Cmd) != 3 || launch.Cmd[0] != "zmx" || launch.Cmd[2] != "work.0.2" {
+		t.Errorf("local Cmd = %q, want zmx attach work.0.2", launch.Cmd)
+	}
+	if _, ok := launch.Vars["kmux_host"]; ok {
+		t.Errorf("local Vars = %v, want no kmux_host", launch.Vars)
+	}
+}
+
+func TestZoomedLayout(t *testing.T) {
+	tab := &kitty.Tab{
+		Layout: "stack",
+		Windows: []kitty.Window{
+			{ID: 1},
+			{ID: 2, UserVars: map[string]string{ZoomVar: "splits"}},
+		},
+	}
+	if layout, id := zoomedLayout(tab); layout != "splits" || id != 2 {
+		t.Errorf("zoomedLayout() = %q, %d, want splits, 2", layout, id)
+	}
+
+	// A stack tab kmux didn't zoom has nothing to go back to
+	tab.Windows[1].UserVars = nil
+	if layout, _ := zoomedLayout(tab); layout != "" {
+		t.Errorf("zoomedLayout() = %q for unzoomed stack tab", layout)
+	}
+
+	tab.Layout = "tall"
+	tab.Windows[1].UserVars = map[string]string{ZoomVar: "splits"}
+	if layout, _ := zoomedLayout(tab); layout != "" {
+		t.Errorf("zoomedLayout() = %q for tab not in stack", layout)
+	}
+}
+
+func TestTabAnchor(t *testing.T) {
+	tab := func(winID int, vars map[string]string) kitty.Tab {
+		return kitty.Tab{Windows: []kitty.Window{{ID: winID, UserVars: vars}}}
+	}
+	pinned := map[string]string{"PINNED": "1"}
+	state := kitty.KittyState{{
+		IsActive: true,
+		Tabs:     []kitty.Tab{tab(1, nil), tab(2, nil), tab(3, pinned), tab(4, nil)},
+	}}
+
+	tests := []struct {
+		position     int
+		beforePinned bool
+		want         int
+	}{
+		{2, false, 2}, // back before the tab that followed it
+		{9, false, 0}, // was last: append
+		{0, false, 0}, // unknown position: append
+		{0, true, 3},  // unknown position: before pinned tabs
+		{2, true, 2},  // saved position is left of the pinned tab
+		{4, true, 3},  // never right of the first pinned tab
+		{1, true, 1},
+	}
+	for _, tt := range tests {
+		if got := tabAnchor(state, tt.position, tt.beforePinned); got != tt.want {
+			t.Errorf("tabAnchor(position=%d, beforePinned=%v) = %d, want %d", tt.position, tt.beforePinned, got, tt.want)
+		}
+	}
+}
+
+func TestZoomedSessionKeepsSplits(t *testing.T) {
+	// A splits tab with a vertical spine whose bottom half is split again
+	g1, g2, g3 := 1, 2, 3
+	vars := func(zmxName string) map[string]string {
+		return map[string]string{"kmux_session": "work", "kmux_zmx": zmxName}
+	}
+	unzoomed := kitty.KittyState{{ID: 1, IsActive: true, Tabs: []kitty.Tab{{
+		ID: 1, IsActive: true, Title: "work", Layout: "splits",
+		LayoutState: kitty.LayoutState{
+			AllWindows: &kitty.AllWindows{WindowGroups: []kitty.WindowGroup{
+				{ID: 1, WindowIDs: []int{10}}, {ID: 2, WindowIDs: []int{11}}, {ID: 3, WindowIDs: []int{12}},
+			}},
+			Pairs: &kitty.Pair{
+				Horizontal: false, Bias: 0.3,
+				One: &kitty.Pair{GroupID: &g1},
+				Two: &kitty.Pair{
+					Horizontal: true, Bias: 0.6,
+					One: &kitty.Pair{GroupID: &g2},
+					Two: &kitty.Pair{GroupID: &g3},
+				},
+			},
+		},
+		Windows: []kitty.Window{
+			{ID: 10, CWD: "/tmp", UserVars: vars("work.0.0")},
+			{ID: 11, CWD: "/tmp", IsActive: true, UserVars: vars("work.0.1")},
+			{ID: 12, CWD: "/tmp", UserVars: vars("work.0.2")},
+		},
+	}}}}
+
+	// Stub kitty reports the unzoomed state and records set-user-vars
+	dir := t.TempDir()
+	stateFile := filepath.Join(dir, "ls.json")
+	varsFile := filepath.Join(dir, "user-vars")
+	data, err := json.Marshal(unzoomed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(stateFile, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	script := `#!/bin/sh
+case "$*" in
+*" ls"*) cat ` + stateFile + ` ;;
+*set-user-vars*) for a; do last=$a; done; printf '%s\n' "$last" >> ` + varsFile + ` ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(dir, "kitty"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	if layout, err := ToggleZoom(kitty.NewClientWithExplicitSocket("unix:" + filepath.Join(dir, "kitty.sock"))); err != nil || layout != "stack" {
+		t.Fatalf("ToggleZoom() = %q, %v, want stack", layout, err)
+	}
+
+	// What kitty reports once zoomed: stack, no split state, the zoom vars
+	// on the active window
+	var zoomed kitty.KittyState
+	if err := json.Unmarshal(data, &zoomed); err != nil {
+		t.Fatal(err)
+	}
+	tab := &zoomed[0].Tabs[0]
+	tab.Layout = "stack"
+	tab.LayoutState = kitty.LayoutState{}
+	set, err := os.ReadFile(varsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(set)), "\n") {
+		key, val, _ := strings.Cut(line, "=")
+		tab.Windows[1].UserVars[key] = val
+	}
+
+	// Save the zoomed session and read it back
+	st := store.New(t.TempDir())
+	if err := st.SaveSession(DeriveSession("work", "local", zoomed)); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := st.LoadSession("work")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := DeriveSession("work", "local", unzoomed).Tabs[0]
+	got := saved.Tabs[0]
+	if got.Layout != "splits" {
+		t.Errorf("saved layout = %q, want splits", got.Layout)
+	}
+	if !reflect.DeepEqual(got.SplitRoot, want.SplitRoot) {
+		t.Errorf("saved split tree = %+v, want %+v", got.SplitRoot, want.SplitRoot)
+	}
+
+	// Restoring places both splits again
+	var buf bytes.Buffer
+	if _, _, err := RestoreTab(fakeKitty(t, &buf), saved, 0, got); err != nil {
+		t.Fatalf("RestoreTab() error = %v", err)
+	}
+	trace := buf.String()
+	if !strings.Contains(trace, "--location hsplit") || !strings.Contains(trace, "--location vsplit") {
+		t.Errorf("restore did not recreate both splits:\n%s", trace)
+	}
+}
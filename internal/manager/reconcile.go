@@ -0,0 +1,175 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/cwel/kmux/internal/config"
+	"github.com/cwel/kmux/internal/kitty"
+	"github.com/cwel/kmux/internal/model"
+	"github.com/cwel/kmux/internal/state"
+	"github.com/cwel/kmux/internal/store"
+)
+
+// WindowRepair holds the kmux user_vars to reapply to a window that lost them.
+type WindowRepair struct {
+	WindowID int
+	Session  string
+	Zmx      string
+	Host     string // "local" or SSH alias
+	ReadOnly bool
+}
+
+// Vars returns the user_vars restoring the window's session membership,
+// matching what restore sets at launch.
+func (r WindowRepair) Vars() map[string]string {
+	vars := map[string]string{
+		"kmux_session": r.Session,
+		"kmux_zmx":     r.Zmx,
+	}
+	if r.Host != "local" {
+		vars["kmux_host"] = r.Host
+	}
+	if r.ReadOnly {
+		vars["kmux_readonly"] = "1"
+	}
+	return vars
+}
+
+// PlanReconcile finds windows running a zmx attach without kmux user_vars,
+// e.g. after kitty restored them from a session file. The session comes from
+// the ownership file (renamed sessions) or the zmx naming convention.
+func PlanReconcile(s *state.State) ([]WindowRepair, error) {
+	kittyState, err := s.KittyClient().GetState()
+	if err != nil {
+		return nil, fmt.Errorf("get kitty state: %w", err)
+	}
+	var hosts map[string]config.HostConfig
+	if cfg := s.Config(); cfg != nil {
+		hosts = cfg.Hosts
+	}
+	return planReconcile(kittyState, store.GetSessionForZmx, hosts), nil
+}
+
+// Reconcile reapplies kmux user_vars to windows found by PlanReconcile.
+// Returns the repairs that were applied.
+func Reconcile(s *state.State) ([]WindowRepair, error) {
+	repairs, err := PlanReconcile(s)
+	if err != nil {
+		return nil, err
+	}
+
+	k := s.KittyClient()
+	var applied []WindowRepair
+	var errs []error
+	for _, r := range repairs {
+		var failed bool
+		for key, val := range r.Vars() {
+			if err := k.SetUserVar(r.WindowID, key, val); err != nil {
+				errs = append(errs, fmt.Errorf("window %d: %w", r.WindowID, err))
+				failed = true
+				break
+			}
+		}
+		if !failed {
+			applied = append(applied, r)
+		}
+	}
+	return applied, errors.Join(errs...)
+}
+
+// planReconcile builds repairs from kitty state. owner maps a local zmx name
+// to its session ("" when unknown); hosts maps ssh destinations back to
+// their aliases.
+func planReconcile(kittyState kitty.KittyState, owner func(zmxName string) string, hosts map[string]config.HostConfig) []WindowRepair {
+	var repairs []WindowRepair
+	for _, osWin := range kittyState {
+		for _, tab := range osWin.Tabs {
+			for _, win := range tab.Windows {
+				if win.UserVars["kmux_session"] != "" {
+					continue
+				}
+				zmxName, host, readOnly, ok := parseAttachCmdline(win.Cmdline, hosts)
+				if !ok {
+					continue
+				}
+
+				session := ""
+				if host == "local" {
+					session = owner(zmxName)
+				}
+				if session == "" {
+					session = model.ParseZmxSessionName(zmxName)
+				}
+				if session == "" {
+					continue
+				}
+
+				repairs = append(repairs, WindowRepair{
+					WindowID: win.ID,
+					Session:  session,
+					Zmx:      zmxName,
+					Host:     host,
+					ReadOnly: readOnly,
+				})
+			}
+		}
+	}
+	return repairs
+}
+
+// parseAttachCmdline recognizes the commands built by zmx.Client.AttachCmd:
+// "zmx attach [--read-only] NAME ..." locally, or
+// "kitten ssh -t [-p PORT] DEST 'zmx attach [--read-only] NAME ...'" for
+// remote hosts, where the destination is mapped back to its alias in hosts.
+func parseAttachCmdline(cmdline []string, hosts map[string]config.HostConfig) (zmxName, host string, readOnly, ok bool) {
+	host = "local"
+	args := cmdline
+	if len(args) >= 2 && filepath.Base(args[0]) == "kitten" && args[1] == "ssh" {
+		// Skip ssh flags to reach the destination, then split the remote command
+		var dest []string
+		i := 2
+		for i < len(args) && strings.HasPrefix(args[i], "-") {
+			if args[i] == "-p" && i+1 < len(args) {
+				dest = append(dest, "-p", args[i+1])
+				i++ // port value
+			}
+			i++
+		}
+		if i+1 >= len(args) {
+			return "", "", false, false
+		}
+		host = hostAlias(hosts, append(dest, args[i]))
+		args = strings.Fields(args[i+1])
+	}
+
+	if len(args) < 3 || !strings.HasPrefix(filepath.Base(args[0]), "zmx") || args[1] != "attach" {
+		return "", "", false, false
+	}
+	rest := args[2:]
+	if rest[0] == "--read-only" {
+		readOnly = true
+		rest = rest[1:]
+	}
+	if len(rest) == 0 || model.ParseZmxSessionName(rest[0]) == "" {
+		return "", "", false, false
+	}
+	return rest[0], host, readOnly, true
+}
+
+// hostAlias returns the alias in hosts whose ssh destination (see
+// config.HostConfig.SSHDestination) is dest. A destination no host builds
+// is returned as is, without the port.
+func hostAlias(hosts map[string]config.HostConfig, dest []string) string {
+	for _, alias := range slices.Sorted(maps.Keys(hosts)) {
+		h := hosts[alias]
+		if slices.Equal(h.SSHDestination(alias), dest) {
+			return alias
+		}
+	}
+	return dest[len(dest)-1]
+}
@@ -0,0 +1,79 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/cwel/kmux/internal/config"
+	"github.com/cwel/kmux/internal/kitty"
+)
+
+func TestParseAttachCmdline(t *testing.T) {
+	hosts := map[string]config.HostConfig{
+		"devbox": {},
+		"lab":    {SSHTarget: "me@10.0.0.5", Port: 2222},
+	}
+	tests := []struct {
+		cmdline  []string
+		zmx      string
+		host     string
+		readOnly bool
+		ok       bool
+	}{
+		{[]string{"zmx", "attach", "proj.0.1"}, "proj.0.1", "local", false, true},
+		{[]string{"/opt/bin/zmx", "attach", "--read-only", "proj.1.0", "/bin/zsh", "-ic", "nvim"}, "proj.1.0", "local", true, true},
+		{[]string{"kitten", "ssh", "-t", "devbox", `zmx attach api.0.0 sh -ic "make run"`}, "api.0.0", "devbox", false, true},
+		{[]string{"kitten", "ssh", "-t", "-p", "2222", "me@10.0.0.5", "zmx attach api.0.1"}, "api.0.1", "lab", false, true},
+		{[]string{"kitten", "ssh", "-t", "me@10.0.0.5", "zmx attach api.0.2"}, "api.0.2", "me@10.0.0.5", false, true},
+		{[]string{"zmx", "attach", "not-a-zmx-name"}, "", "", false, false},
+		{[]string{"/bin/zsh"}, "", "", false, false},
+		{[]string{"kitten", "ssh", "-t"}, "", "", false, false},
+	}
+
+	for _, tt := range tests {
+		zmx, host, readOnly, ok := parseAttachCmdline(tt.cmdline, hosts)
+		if ok != tt.ok || zmx != tt.zmx || host != tt.host || readOnly != tt.readOnly {
+			t.Errorf("parseAttachCmdline(%q) = (%q, %q, %v, %v), want (%q, %q, %v, %v)",
+				tt.cmdline, zmx, host, readOnly, ok, tt.zmx, tt.host, tt.readOnly, tt.ok)
+		}
+	}
+}
+
+func TestPlanReconcile(t *testing.T) {
+	kittyState := kitty.KittyState{
+		{ID: 1, Tabs: []kitty.Tab{
+			{ID: 10, Windows: []kitty.Window{
+				// Already tagged: left alone
+				{ID: 100, Cmdline: []string{"zmx", "attach", "proj.0.0"}, UserVars: map[string]string{"kmux_session": "proj"}},
+				// Lost its vars; zmx name is from before a rename
+				{ID: 101, Cmdline: []string{"zmx", "attach", "old.0.1"}},
+				// Remote window
+				{ID: 102, Cmdline: []string{"kitten", "ssh", "-t", "devbox", "zmx attach api.0.0"}},
+				// Plain shell, not kmux
+				{ID: 103, Cmdline: []string{"/bin/zsh"}},
+			}},
+		}},
+	}
+	owner := func(zmxName string) string {
+		if zmxName == "old.0.1" {
+			return "proj"
+		}
+		return ""
+	}
+
+	repairs := planReconcile(kittyState, owner, nil)
+	if len(repairs) != 2 {
+		t.Fatalf("got %d repairs, want 2: %+v", len(repairs), repairs)
+	}
+	if r := repairs[0]; r.WindowID != 101 || r.Session != "proj" || r.Zmx != "old.0.1" || r.Host != "local" {
+		t.Errorf("repairs[0] = %+v, want window 101 -> proj", r)
+	}
+	if r := repairs[1]; r.WindowID != 102 || r.Session != "api" || r.Host != "devbox" {
+		t.Errorf("repairs[1] = %+v, want window 102 -> api@devbox", r)
+	}
+	if vars := repairs[1].Vars(); vars["kmux_host"] != "devbox" {
+		t.Errorf("remote vars = %v, want kmux_host=devbox", vars)
+	}
+	if _, ok := repairs[0].Vars()["kmux_host"]; ok {
+		t.Error("local vars should not set kmux_host")
+	}
+}
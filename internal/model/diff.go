@@ -0,0 +1,111 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Change is one difference between a saved session and its current state.
+// Tabs and windows are matched by position.
+type Change struct {
+	Kind   string `json:"kind"`   // tab-added, tab-removed, title, layout, bias, split, window-added, window-removed, cwd, command
+	Tab    int    `json:"tab"`    // tab index
+	Window int    `json:"window"` // window index within the tab, -1 for tab-level changes
+	Old    string `json:"old,omitempty"`
+	New    string `json:"new,omitempty"`
+}
+
+// DiffSessions lists what changed from saved to current: added and removed
+// tabs and windows, retitled tabs, layout and split changes, and windows
+// whose cwd or command changed.
+func DiffSessions(saved, current *Session) []Change {
+	var changes []Change
+	for i := 0; i < max(len(saved.Tabs), len(current.Tabs)); i++ {
+		switch {
+		case i >= len(saved.Tabs):
+			changes = append(changes, Change{Kind: "tab-added", Tab: i, Window: -1, New: current.Tabs[i].Title})
+		case i >= len(current.Tabs):
+			changes = append(changes, Change{Kind: "tab-removed", Tab: i, Window: -1, Old: saved.Tabs[i].Title})
+		default:
+			changes = append(changes, diffTab(i, &saved.Tabs[i], &current.Tabs[i])...)
+		}
+	}
+	return changes
+}
+
+func diffTab(idx int, saved, current *Tab) []Change {
+	var changes []Change
+	tabChange := func(kind, old, new string) {
+		if old != new {
+			changes = append(changes, Change{Kind: kind, Tab: idx, Window: -1, Old: old, New: new})
+		}
+	}
+	tabChange("title", saved.Title, current.Title)
+	tabChange("layout", saved.Layout, current.Layout)
+	tabChange("bias", strconv.Itoa(saved.Bias), strconv.Itoa(current.Bias))
+
+	// Split trees: report a reshaped tree once, otherwise each moved divider
+	if oldShape, newShape := splitShape(saved.SplitRoot), splitShape(current.SplitRoot); oldShape != newShape {
+		tabChange("split", oldShape, newShape)
+	} else {
+		oldBias, newBias := splitBiases(saved.SplitRoot, nil), splitBiases(current.SplitRoot, nil)
+		for i := range oldBias {
+			if math.Abs(oldBias[i]-newBias[i]) >= 0.01 {
+				tabChange("bias", fmt.Sprintf("%.2f", oldBias[i]), fmt.Sprintf("%.2f", newBias[i]))
+			}
+		}
+	}
+
+	for i := 0; i < max(len(saved.Windows), len(current.Windows)); i++ {
+		switch {
+		case i >= len(saved.Windows):
+			changes = append(changes, Change{Kind: "window-added", Tab: idx, Window: i, New: windowLabel(current.Windows[i])})
+		case i >= len(current.Windows):
+			changes = append(changes, Change{Kind: "window-removed", Tab: idx, Window: i, Old: windowLabel(saved.Windows[i])})
+		default:
+			old, cur := saved.Windows[i], current.Windows[i]
+			if old.CWD != cur.CWD {
+				changes = append(changes, Change{Kind: "cwd", Tab: idx, Window: i, Old: old.CWD, New: cur.CWD})
+			}
+			if old.Command != cur.Command {
+				changes = append(changes, Change{Kind: "command", Tab: idx, Window: i, Old: old.Command, New: cur.Command})
+			}
+		}
+	}
+	return changes
+}
+
+// windowLabel describes a window by its command, or its cwd for shells.
+func windowLabel(w Window) string {
+	if w.Command != "" {
+		return w.Command
+	}
+	return w.CWD
+}
+
+// splitShape renders a split tree's structure without biases,
+// e.g. "h(0,v(1,2))".
+func splitShape(n *SplitNode) string {
+	if n == nil {
+		return ""
+	}
+	if n.IsLeaf() {
+		return strconv.Itoa(*n.WindowIdx)
+	}
+	dir := "v"
+	if n.Horizontal {
+		dir = "h"
+	}
+	return dir + "(" + splitShape(n.Children[0]) + "," + splitShape(n.Children[1]) + ")"
+}
+
+// splitBiases collects branch biases in pre-order.
+func splitBiases(n *SplitNode, out []float64) []float64 {
+	if n == nil || n.IsLeaf() {
+		return out
+	}
+	out = append(out, n.Bias)
+	out = splitBiases(n.Children[0], out)
+	return splitBiases(n.Children[1], out)
+}
@@ -0,0 +1,61 @@
+package model
+
+import "testing"
+
+func TestDiffSessions(t *testing.T) {
+	leaf := func(i int) *SplitNode { return &SplitNode{WindowIdx: &i} }
+	saved := &Session{Tabs: []Tab{
+		{
+			Title:     "editor",
+			Layout:    "splits",
+			Windows:   []Window{{CWD: "/src", Command: "nvim ."}, {CWD: "/src"}},
+			SplitRoot: &SplitNode{Horizontal: true, Bias: 0.5, Children: [2]*SplitNode{leaf(0), leaf(1)}},
+		},
+		{Title: "logs", Windows: []Window{{CWD: "/var/log"}}},
+	}}
+	current := &Session{Tabs: []Tab{
+		{
+			Title:     "editor",
+			Layout:    "splits",
+			Windows:   []Window{{CWD: "/src", Command: "nvim ."}, {CWD: "/src/api"}, {CWD: "/src", Command: "make test"}},
+			SplitRoot: &SplitNode{Horizontal: true, Bias: 0.7, Children: [2]*SplitNode{leaf(0), leaf(1)}},
+		},
+	}}
+
+	got := DiffSessions(saved, current)
+	want := []Change{
+		{Kind: "bias", Tab: 0, Window: -1, Old: "0.50", New: "0.70"},
+		{Kind: "cwd", Tab: 0, Window: 1, Old: "/src", New: "/src/api"},
+		{Kind: "window-added", Tab: 0, Window: 2, New: "make test"},
+		{Kind: "tab-removed", Tab: 1, Window: -1, Old: "logs"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("DiffSessions() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("change %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if changes := DiffSessions(saved, saved); len(changes) != 0 {
+		t.Errorf("DiffSessions(saved, saved) = %+v, want none", changes)
+	}
+}
+
+func TestDiffSessions_SplitReshaped(t *testing.T) {
+	leaf := func(i int) *SplitNode { return &SplitNode{WindowIdx: &i} }
+	saved := &Session{Tabs: []Tab{{
+		Windows:   []Window{{}, {}},
+		SplitRoot: &SplitNode{Horizontal: true, Bias: 0.5, Children: [2]*SplitNode{leaf(0), leaf(1)}},
+	}}}
+	current := &Session{Tabs: []Tab{{
+		Windows:   []Window{{}, {}},
+		SplitRoot: &SplitNode{Bias: 0.5, Children: [2]*SplitNode{leaf(0), leaf(1)}},
+	}}}
+
+	got := DiffSessions(saved, current)
+	if len(got) != 1 || got[0].Kind != "split" || got[0].Old != "h(0,1)" || got[0].New != "v(0,1)" {
+		t.Errorf("DiffSessions() = %+v, want one split change h(0,1) -> v(0,1)", got)
+	}
+}
@@ -2,32 +2,47 @@ package model
 
 import (
 	"strconv"
+	"strings"
 	"time"
 )
 
+// CurrentSchemaVersion is the save file format written by this version of kmux.
+// Version 0 files predate per-window ZmxName and may lack Host.
+const CurrentSchemaVersion = 1
+
 // Session represents a kmux session with its layout and state.
 type Session struct {
+	SchemaVersion int `json:"schema_version"`
+
 	Name        string    `json:"name"`
 	Host        string    `json:"host"`
 	SavedAt     time.Time `json:"saved_at"`
+	LayoutName  string    `json:"layout_name,omitempty"` // layout template used at creation
 	Tabs        []Tab     `json:"tabs"`
 	ZmxSessions []string  `json:"zmx_sessions"`
+
+	NoSave bool `json:"-"` // opted out of saving (kmux_no_save user var); never persisted
 }
 
 // Tab represents a kitty tab containing windows.
 type Tab struct {
-	Title     string     `json:"title"`
-	Layout    string     `json:"layout"`
-	Windows   []Window   `json:"windows"`
-	SplitRoot *SplitNode `json:"split_root,omitempty"` // nil for single-window tabs
+	Title      string     `json:"title"`
+	Layout     string     `json:"layout"`
+	Bias       int        `json:"bias,omitempty"` // main pane percentage for simple layouts (0 = default)
+	Windows    []Window   `json:"windows"`
+	SplitRoot  *SplitNode `json:"split_root,omitempty"`   // nil for single-window tabs
+	OSWindowID int        `json:"os_window_id,omitempty"` // kitty OS window the tab was in
+	Position   int        `json:"position,omitempty"`     // 1-based place in that OS window's tab bar (0 = unknown)
 }
 
 // Window represents a single pane in a tab.
 type Window struct {
-	CWD       string `json:"cwd"`
-	Command   string `json:"command,omitempty"`
-	Ephemeral bool   `json:"ephemeral,omitempty"`
-	ZmxName   string `json:"zmx_name,omitempty"` // Actual zmx session name
+	CWD       string            `json:"cwd"`
+	Command   string            `json:"command,omitempty"`
+	Title     string            `json:"title,omitempty"` // window title, derived from the running command
+	Ephemeral bool              `json:"ephemeral,omitempty"`
+	ZmxName   string            `json:"zmx_name,omitempty"` // Actual zmx session name
+	Env       map[string]string `json:"env,omitempty"`      // allowlisted environment, replayed on restore
 }
 
 // SplitNode represents a node in the split tree.
@@ -87,3 +102,42 @@ func ParseZmxSessionName(zmxName string) string {
 
 	return zmxName[:secondLastDot]
 }
+
+// ParseZmxIndices extracts the tab and window indices from a zmx session name.
+// Returns ok=false if the name doesn't match kmux's naming convention.
+func ParseZmxIndices(zmxName string) (tabIdx, winIdx int, ok bool) {
+	session := ParseZmxSessionName(zmxName)
+	if session == "" {
+		return 0, 0, false
+	}
+	parts := strings.SplitN(zmxName[len(session)+1:], ".", 2)
+	tabIdx, _ = strconv.Atoi(parts[0])
+	winIdx, _ = strconv.Atoi(parts[1])
+	return tabIdx, winIdx, true
+}
+
+// DedupeZmxSessions drops zmx sessions that map to the same kmux pane
+// ({session, tabIdx, winIdx}) as a later entry, e.g. an old process still
+// listed after a crash and restart. The later entry is treated as the newest.
+// Names outside kmux's naming convention are kept as-is. Order is preserved.
+func DedupeZmxSessions(zmxNames []string) []string {
+	type paneKey struct {
+		session  string
+		tab, win int
+	}
+	last := make(map[paneKey]int)
+	for i, name := range zmxNames {
+		if tab, win, ok := ParseZmxIndices(name); ok {
+			last[paneKey{ParseZmxSessionName(name), tab, win}] = i
+		}
+	}
+
+	var kept []string
+	for i, name := range zmxNames {
+		if tab, win, ok := ParseZmxIndices(name); ok && last[paneKey{ParseZmxSessionName(name), tab, win}] != i {
+			continue
+		}
+		kept = append(kept, name)
+	}
+	return kept
+}
@@ -83,6 +83,42 @@ func TestParseZmxSessionName(t *testing.T) {
 	}
 }
 
+func TestZmxSessionNameRoundTrip(t *testing.T) {
+	for _, name := range []string{"plain", "my.app", "a.b.c", "v1.2.3"} {
+		s := &Session{Name: name}
+		zmxName := s.ZmxSessionName(1, 2)
+		if got := ParseZmxSessionName(zmxName); got != name {
+			t.Errorf("ParseZmxSessionName(%q) = %q, want %q", zmxName, got, name)
+		}
+		if tab, win, ok := ParseZmxIndices(zmxName); !ok || tab != 1 || win != 2 {
+			t.Errorf("ParseZmxIndices(%q) = (%d, %d, %v), want (1, 2, true)", zmxName, tab, win, ok)
+		}
+	}
+}
+
+func TestParseZmxIndices(t *testing.T) {
+	tests := []struct {
+		zmxName string
+		tab     int
+		win     int
+		ok      bool
+	}{
+		{"myproject.0.0", 0, 0, true},
+		{"work.2.5", 2, 5, true},
+		{"a.b.c.1.2", 1, 2, true},
+		{"standalone", 0, 0, false},
+		{"foo.0.bar", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		tab, win, ok := ParseZmxIndices(tt.zmxName)
+		if tab != tt.tab || win != tt.win || ok != tt.ok {
+			t.Errorf("ParseZmxIndices(%q) = (%d, %d, %v), want (%d, %d, %v)",
+				tt.zmxName, tab, win, ok, tt.tab, tt.win, tt.ok)
+		}
+	}
+}
+
 func TestSplitNode_IsLeaf(t *testing.T) {
 	idx := 0
 	leaf := &SplitNode{WindowIdx: &idx}
@@ -125,3 +161,23 @@ func TestTab_WithSplitRoot(t *testing.T) {
 		t.Error("first child should be leaf")
 	}
 }
+
+func TestDedupeZmxSessions(t *testing.T) {
+	got := DedupeZmxSessions([]string{
+		"proj.0.0",
+		"proj.0.1",
+		"proj.0.0", // relisted after a crash+restart
+		"proj.00.1",
+		"scratch",
+		"scratch",
+	})
+	want := []string{"proj.0.0", "proj.00.1", "scratch", "scratch"}
+	if len(got) != len(want) {
+		t.Fatalf("DedupeZmxSessions() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("DedupeZmxSessions()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
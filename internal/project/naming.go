@@ -0,0 +1,53 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Session naming strategies for cwd-derived names ([sessions] name_strategy).
+const (
+	NameBasename        = "basename"          // ~/src/acme/api -> api
+	NameParentBasename  = "parent-basename"   // ~/src/acme/api -> acme-api
+	NameGitRootBasename = "git-root-basename" // ~/src/acme/api/cmd -> api (repo root)
+)
+
+// SessionName derives a session name from a directory using strategy.
+// An empty strategy means basename.
+func SessionName(dir, strategy string) (string, error) {
+	dir = filepath.Clean(dir)
+	switch strategy {
+	case "", NameBasename:
+		return filepath.Base(dir), nil
+	case NameParentBasename:
+		parent := filepath.Dir(dir)
+		if parent == dir || parent == string(filepath.Separator) {
+			return filepath.Base(dir), nil
+		}
+		return filepath.Base(parent) + "-" + filepath.Base(dir), nil
+	case NameGitRootBasename:
+		if root := gitRoot(dir); root != "" {
+			return filepath.Base(root), nil
+		}
+		return filepath.Base(dir), nil
+	default:
+		return "", fmt.Errorf("unknown name strategy %q (use %s, %s or %s)",
+			strategy, NameBasename, NameParentBasename, NameGitRootBasename)
+	}
+}
+
+// gitRoot returns the nearest ancestor of dir (inclusive) containing .git,
+// or "" if dir is not inside a git repository.
+func gitRoot(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
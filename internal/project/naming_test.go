@@ -0,0 +1,45 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSessionName(t *testing.T) {
+	root := t.TempDir()
+	repo := filepath.Join(root, "acme", "api")
+	sub := filepath.Join(repo, "cmd", "server")
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	plain := filepath.Join(root, "notes")
+
+	tests := []struct {
+		dir, strategy, want string
+	}{
+		{repo, "", "api"},
+		{repo, NameBasename, "api"},
+		{repo, NameParentBasename, "acme-api"},
+		{"/api", NameParentBasename, "api"},
+		{sub, NameGitRootBasename, "api"},
+		{plain, NameGitRootBasename, "notes"},
+	}
+	for _, tt := range tests {
+		got, err := SessionName(tt.dir, tt.strategy)
+		if err != nil {
+			t.Errorf("SessionName(%q, %q) error = %v", tt.dir, tt.strategy, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("SessionName(%q, %q) = %q, want %q", tt.dir, tt.strategy, got, tt.want)
+		}
+	}
+
+	if _, err := SessionName(repo, "bogus"); err == nil {
+		t.Error("SessionName() expected error for unknown strategy")
+	}
+}
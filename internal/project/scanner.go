@@ -1,6 +1,10 @@
 package project
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
@@ -54,6 +58,45 @@ func (s *Scanner) Scan() []Project {
 	return projects
 }
 
+// CheckDirs reports configured directories that Scan skips because they
+// don't exist, aren't directories, or can't be read.
+func (s *Scanner) CheckDirs() []error {
+	var problems []error
+	for _, dir := range s.dirs {
+		if err := checkDir(dir); err != nil {
+			problems = append(problems, err)
+		}
+	}
+	return problems
+}
+
+func checkDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) {
+			err = pathErr.Err
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("%s: no such directory", dir)
+		}
+		return fmt.Errorf("%s: %w", dir, err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("%s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s: not a directory", dir)
+	}
+	if _, err := f.Readdirnames(1); err != nil && err != io.EOF {
+		return fmt.Errorf("%s: %w", dir, err)
+	}
+	return nil
+}
+
 // isIgnored checks if a path matches any ignore pattern.
 func (s *Scanner) isIgnored(path string) bool {
 	name := filepath.Base(path)
@@ -136,3 +179,16 @@ func FilterExisting(projects []Project, sessionNames map[string]bool) []Project
 	}
 	return filtered
 }
+
+// Find returns the projects named name, or the project at name when it is
+// a path (contains a separator). Several results mean the name is ambiguous.
+func Find(projects []Project, name string) []Project {
+	var matches []Project
+	isPath := strings.ContainsRune(name, filepath.Separator)
+	for _, p := range projects {
+		if isPath && filepath.Clean(name) == p.Path || !isPath && p.Name == name {
+			matches = append(matches, p)
+		}
+	}
+	return matches
+}
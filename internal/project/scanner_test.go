@@ -0,0 +1,49 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	projects := []Project{
+		{Name: "api", Path: "/src/acme/api"},
+		{Name: "api", Path: "/src/globex/api"},
+		{Name: "web", Path: "/src/acme/web"},
+	}
+
+	if got := Find(projects, "web"); len(got) != 1 || got[0].Path != "/src/acme/web" {
+		t.Errorf("Find(web) = %v, want /src/acme/web", got)
+	}
+	if got := Find(projects, "api"); len(got) != 2 {
+		t.Errorf("Find(api) = %v, want both api projects", got)
+	}
+	if got := Find(projects, "/src/globex/api/"); len(got) != 1 || got[0].Path != "/src/globex/api" {
+		t.Errorf("Find(path) = %v, want /src/globex/api", got)
+	}
+	if got := Find(projects, "missing"); len(got) != 0 {
+		t.Errorf("Find(missing) = %v, want none", got)
+	}
+}
+
+func TestCheckDirs(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "notes.txt")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(root, "typo")
+
+	s := &Scanner{dirs: []string{root, missing, file}}
+	problems := s.CheckDirs()
+	if len(problems) != 2 {
+		t.Fatalf("CheckDirs() = %v, want 2 problems", problems)
+	}
+	if got := problems[0].Error(); got != missing+": no such directory" {
+		t.Errorf("problems[0] = %q", got)
+	}
+	if got := problems[1].Error(); got != file+": not a directory" {
+		t.Errorf("problems[1] = %q", got)
+	}
+}
@@ -2,9 +2,12 @@ package remote
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os/exec"
+	"strings"
 	"time"
 
 	"github.com/cwel/kmux/internal/config"
@@ -22,6 +25,26 @@ type SessionInfo struct {
 	LastSeen       time.Time `json:"LastSeen"`
 }
 
+// DefaultTimeout bounds remote kmux calls made without a caller deadline
+// (e.g. fetching a save file on attach).
+const DefaultTimeout = 30 * time.Second
+
+// TimeoutError reports a remote call that hit its deadline, e.g. an SSH
+// connection to a host that is down.
+type TimeoutError struct {
+	Host string
+	Op   string // e.g. "session list"
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s on %s timed out", e.Op, e.Host)
+}
+
+// Unwrap lets errors.Is(err, context.DeadlineExceeded) match.
+func (e *TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
 // Client communicates with a remote kmux instance over SSH.
 type Client struct {
 	host    string
@@ -43,21 +66,48 @@ func (c *Client) kmuxPath() string {
 
 // runKmux executes a kmux command on the remote host.
 func (c *Client) runKmux(args ...string) *exec.Cmd {
+	return c.runKmuxContext(context.Background(), args...)
+}
+
+// runKmuxContext is runKmux with a context; ssh is killed when ctx is done.
+func (c *Client) runKmuxContext(ctx context.Context, args ...string) *exec.Cmd {
 	kmuxCmd := c.kmuxPath()
 	for _, a := range args {
 		kmuxCmd += " " + a
 	}
-	return exec.Command("ssh", c.host, kmuxCmd)
+	c.hostCfg.EnsureControlMaster(ctx, c.host)
+	sshArgs := append(c.hostCfg.SSHArgs(), c.hostCfg.SSHDestination(c.host)...)
+	sshArgs = append(sshArgs, kmuxCmd)
+	return exec.CommandContext(ctx, "ssh", sshArgs...)
+}
+
+// ctxErr converts an ended context into the error to report for op:
+// a *TimeoutError naming the host on deadline, ctx.Err() otherwise.
+// Returns nil while ctx is still live.
+func (c *Client) ctxErr(ctx context.Context, op string) error {
+	err := ctx.Err()
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &TimeoutError{Host: c.host, Op: op}
+	}
+	return err
 }
 
 // ListSessions returns sessions from the remote host.
 func (c *Client) ListSessions() ([]SessionInfo, error) {
-	cmd := c.runKmux("session", "list")
+	return c.ListSessionsContext(context.Background())
+}
+
+// ListSessionsContext is ListSessions bounded by ctx.
+func (c *Client) ListSessionsContext(ctx context.Context) ([]SessionInfo, error) {
+	cmd := c.runKmuxContext(ctx, "session", "list")
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
+		if ctxErr := c.ctxErr(ctx, "session list"); ctxErr != nil {
+			return nil, ctxErr
+		}
 		return nil, fmt.Errorf("remote kmux session list: %w: %s", err, stderr.String())
 	}
 
@@ -71,12 +121,20 @@ func (c *Client) ListSessions() ([]SessionInfo, error) {
 
 // GetSession returns a session's save file from the remote host.
 func (c *Client) GetSession(name string) (*model.Session, error) {
-	cmd := c.runKmux("session", "get", name)
+	return c.GetSessionContext(context.Background(), name)
+}
+
+// GetSessionContext is GetSession bounded by ctx.
+func (c *Client) GetSessionContext(ctx context.Context, name string) (*model.Session, error) {
+	cmd := c.runKmuxContext(ctx, "session", "get", name)
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
+		if ctxErr := c.ctxErr(ctx, "session get "+name); ctxErr != nil {
+			return nil, ctxErr
+		}
 		return nil, fmt.Errorf("remote kmux session get %s: %w: %s", name, err, stderr.String())
 	}
 
@@ -122,7 +180,30 @@ func (c *Client) DeleteSession(name string) error {
 
 // Kill tells the remote kmux to kill a session (zmx + save file).
 func (c *Client) Kill(name string) error {
-	cmd := c.runKmux("kill", name)
+	return c.kill(name)
+}
+
+// KillZmx tells the remote kmux to kill a session's zmx sessions but keep
+// its save file (kill --zmx-only). A kmux too old for --zmx-only gets a
+// plain kill instead, with the save file fetched first and written back.
+func (c *Client) KillZmx(name string) error {
+	err := c.kill(name, "--zmx-only")
+	if err == nil || !strings.Contains(err.Error(), "unknown flag: --zmx-only") {
+		return err
+	}
+
+	saved, getErr := c.GetSession(name) // no save file: nothing to keep
+	if err := c.Kill(name); err != nil {
+		return err
+	}
+	if getErr != nil {
+		return nil
+	}
+	return c.SaveSession(saved)
+}
+
+func (c *Client) kill(name string, flags ...string) error {
+	cmd := c.runKmux(append(append([]string{"kill"}, flags...), name)...)
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
@@ -0,0 +1,94 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListSessionsContextTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+
+	_, err := NewClient("devbox", nil).ListSessionsContext(ctx)
+	var timeoutErr *TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("ListSessionsContext() error = %v, want *TimeoutError", err)
+	}
+	if timeoutErr.Host != "devbox" {
+		t.Errorf("Host = %q, want devbox", timeoutErr.Host)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("error should match context.DeadlineExceeded")
+	}
+}
+
+func TestGetSessionContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewClient("devbox", nil).GetSessionContext(ctx, "proj")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("GetSessionContext() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestKillZmx(t *testing.T) {
+	// Stub ssh records the remote command line it was asked to run
+	dir := t.TempDir()
+	out := filepath.Join(dir, "args")
+	script := "#!/bin/sh\nfor a; do last=$a; done\necho \"$last\" > " + out + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "ssh"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	if err := NewClient("devbox", nil).KillZmx("proj"); err != nil {
+		t.Fatalf("KillZmx() error = %v", err)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "kmux kill --zmx-only proj\n"; string(got) != want {
+		t.Errorf("remote command = %q, want %q", got, want)
+	}
+}
+
+func TestKillZmxOldRemote(t *testing.T) {
+	// Stub ssh for a kmux without --zmx-only: its kill deletes the save
+	// file, which the client must write back
+	dir := t.TempDir()
+	saveFile := filepath.Join(dir, "proj.json")
+	script := `#!/bin/sh
+for a; do last=$a; done
+case "$last" in
+*--zmx-only*) echo "Error: unknown flag: --zmx-only" >&2; exit 1 ;;
+*"session get proj") cat ` + saveFile + ` ;;
+*"kill proj") rm ` + saveFile + ` ;;
+*"session save proj") cat > ` + saveFile + ` ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(dir, "ssh"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(saveFile, []byte(`{"name":"proj","host":"local","tabs":[]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	if err := NewClient("devbox", nil).KillZmx("proj"); err != nil {
+		t.Fatalf("KillZmx() error = %v", err)
+	}
+	data, err := os.ReadFile(saveFile)
+	if err != nil {
+		t.Fatalf("save file not written back: %v", err)
+	}
+	if !strings.Contains(string(data), `"name":"proj"`) {
+		t.Errorf("save file = %s, want session proj", data)
+	}
+}
@@ -0,0 +1,49 @@
+package state
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SortKeys lists the accepted SortSessions keys.
+var SortKeys = []string{"name", "status", "panes", "last-seen"}
+
+// statusRank orders statuses from most to least live.
+var statusRank = map[string]int{"active": 0, "detached": 1, "saved": 2}
+
+// SortSessions sorts sessions in place by key ("name", "status", "panes" or
+// "last-seen"). Ties fall back to name, then host, so the order is stable
+// across runs. Panes and last-seen sort largest/newest first.
+func SortSessions(sessions []SessionInfo, key string, reverse bool) error {
+	var less func(a, b SessionInfo) bool
+	switch key {
+	case "", "name":
+		less = func(a, b SessionInfo) bool { return false }
+	case "status":
+		less = func(a, b SessionInfo) bool { return statusRank[a.Status] < statusRank[b.Status] }
+	case "panes":
+		less = func(a, b SessionInfo) bool { return a.Panes > b.Panes }
+	case "last-seen":
+		less = func(a, b SessionInfo) bool { return a.LastSeen.After(b.LastSeen) }
+	default:
+		return fmt.Errorf("unknown sort key %q (use name, status, panes or last-seen)", key)
+	}
+
+	sort.SliceStable(sessions, func(i, j int) bool {
+		a, b := sessions[i], sessions[j]
+		if reverse {
+			a, b = b, a
+		}
+		if less(a, b) {
+			return true
+		}
+		if less(b, a) {
+			return false
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Host < b.Host
+	})
+	return nil
+}
@@ -0,0 +1,54 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSortSessions(t *testing.T) {
+	now := time.Now()
+	sessions := func() []SessionInfo {
+		return []SessionInfo{
+			{Name: "web", Host: "local", Status: "saved", Panes: 1, LastSeen: now.Add(-time.Hour)},
+			{Name: "api", Host: "devbox", Status: "detached", Panes: 3, LastSeen: now},
+			{Name: "api", Host: "local", Status: "active", Panes: 2},
+			{Name: "docs", Host: "local", Status: "active", Panes: 3, LastSeen: now.Add(-time.Minute)},
+		}
+	}
+	order := func(s []SessionInfo) []string {
+		var out []string
+		for _, sess := range s {
+			out = append(out, sess.Name+"@"+sess.Host)
+		}
+		return out
+	}
+
+	tests := []struct {
+		key     string
+		reverse bool
+		want    []string
+	}{
+		{"name", false, []string{"api@devbox", "api@local", "docs@local", "web@local"}},
+		{"name", true, []string{"web@local", "docs@local", "api@local", "api@devbox"}},
+		{"status", false, []string{"api@local", "docs@local", "api@devbox", "web@local"}},
+		{"panes", false, []string{"api@devbox", "docs@local", "api@local", "web@local"}},
+		{"last-seen", false, []string{"api@devbox", "docs@local", "web@local", "api@local"}},
+	}
+	for _, tt := range tests {
+		s := sessions()
+		if err := SortSessions(s, tt.key, tt.reverse); err != nil {
+			t.Fatalf("SortSessions(%q) error: %v", tt.key, err)
+		}
+		got := order(s)
+		for i := range tt.want {
+			if got[i] != tt.want[i] {
+				t.Errorf("SortSessions(%q, reverse=%v) = %v, want %v", tt.key, tt.reverse, got, tt.want)
+				break
+			}
+		}
+	}
+
+	if err := SortSessions(sessions(), "size", false); err == nil {
+		t.Error("SortSessions(\"size\") should fail")
+	}
+}
@@ -3,10 +3,12 @@ package state
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cwel/kmux/internal/config"
@@ -25,7 +27,8 @@ type SessionInfo struct {
 	Panes          int
 	IsRestorePoint bool
 	CWD            string
-	LastSeen       time.Time
+	LastSeen       time.Time // save file timestamp (zero if never saved)
+	ReadOnly       bool      // attached read-only (kmux_readonly user var)
 }
 
 // SessionResult holds the result of querying a host for sessions.
@@ -35,6 +38,13 @@ type SessionResult struct {
 	Error    error
 }
 
+// zmxListEntry is a cached zmx list result for one host.
+type zmxListEntry struct {
+	sessions []string
+	err      error
+	at       time.Time
+}
+
 // State provides stateless queries combining kitty, zmx, and save files.
 type State struct {
 	kitty      *kitty.Client
@@ -43,14 +53,32 @@ type State struct {
 	remoteKmux map[string]*remote.Client // SSH alias -> remote kmux client
 	store      *store.Store
 	cfg        *config.Config
+
+	// Short-lived zmx list cache to avoid repeated SSH probes
+	cacheMu  sync.Mutex
+	zmxCache map[string]zmxListEntry // host -> last result
+	cacheTTL time.Duration
+
+	zmxMissing atomic.Bool // local zmx binary not found; zmx features disabled
 }
 
-// New creates a new State with default clients.
-func New() *State {
-	cfg, _ := config.LoadConfig()
-	socketPath := ""
-	if cfg != nil && cfg.Kitty.Socket != "" {
-		socketPath = cfg.Kitty.Socket
+// New loads the config file and creates a State from it. A config that
+// can't be read or parsed is an error rather than a silent fallback to
+// defaults.
+func New() (*State, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	return NewWithConfig(cfg), nil
+}
+
+// NewWithConfig creates a new State with default clients configured by cfg
+// (nil uses the defaults).
+func NewWithConfig(cfg *config.Config) *State {
+	socketPath, socketGlob := "", ""
+	if cfg != nil {
+		socketPath, socketGlob = cfg.Kitty.Socket, cfg.Kitty.SocketGlob
 	}
 
 	// Build remote zmx and kmux clients from config
@@ -64,14 +92,75 @@ func New() *State {
 		}
 	}
 
+	// An explicit socket (--kitty-socket sets KMUX_KITTY_SOCKET) bypasses discovery
+	kittyClient := kitty.NewClientWithSocketGlob(socketPath, socketGlob)
+	if explicit := os.Getenv("KMUX_KITTY_SOCKET"); explicit != "" {
+		kittyClient = kitty.NewClientWithExplicitSocket(explicit)
+	}
+	if cfg != nil {
+		kittyClient.SetRetryAttempts(cfg.Kitty.RetryAttempts)
+	}
+	// KMUX_DEBUG=1 prints every kitty @ command (e.g. launches with their zmx attach line)
+	if os.Getenv("KMUX_DEBUG") == "1" {
+		kittyClient.SetTrace(os.Stderr)
+	}
+
+	cacheTTL := 5 * time.Second
+	if cfg != nil {
+		cacheTTL = time.Duration(cfg.Remote.CacheTTL) * time.Second
+	}
+
 	return &State{
-		kitty:      kitty.NewClientWithSocket(socketPath),
+		kitty:      kittyClient,
 		localZmx:   zmx.NewClient(),
 		remoteZmx:  remoteZmx,
 		remoteKmux: remoteKmux,
 		store:      store.DefaultStore(),
 		cfg:        cfg,
+		zmxCache:   make(map[string]zmxListEntry),
+		cacheTTL:   cacheTTL,
+	}
+}
+
+// CachedZmxList returns the zmx sessions on a host, reusing a result younger
+// than the configured TTL. Pass force to bypass the cache. Safe for concurrent use.
+func (s *State) CachedZmxList(host string, force bool) ([]string, error) {
+	return s.CachedZmxListContext(context.Background(), host, force)
+}
+
+// CachedZmxListContext is CachedZmxList bounded by ctx. Results of a query
+// cut short by ctx are not cached.
+func (s *State) CachedZmxListContext(ctx context.Context, host string, force bool) ([]string, error) {
+	if host == "" {
+		host = "local"
 	}
+
+	s.cacheMu.Lock()
+	entry, ok := s.zmxCache[host]
+	s.cacheMu.Unlock()
+	if ok && !force && time.Since(entry.at) < s.cacheTTL {
+		return entry.sessions, entry.err
+	}
+
+	sessions, err := s.ZmxClientForHost(host).ListContext(ctx)
+	if errors.Is(err, zmx.ErrNoSessions) {
+		err = nil
+	}
+	if ctx.Err() != nil {
+		return sessions, err
+	}
+
+	s.cacheMu.Lock()
+	s.zmxCache[host] = zmxListEntry{sessions: sessions, err: err, at: time.Now()}
+	s.cacheMu.Unlock()
+	return sessions, err
+}
+
+// InvalidateHostCache drops the cached zmx list for a host.
+func (s *State) InvalidateHostCache(host string) {
+	s.cacheMu.Lock()
+	delete(s.zmxCache, host)
+	s.cacheMu.Unlock()
 }
 
 // ZmxClientForHost returns the zmx client for a given host.
@@ -108,7 +197,13 @@ func (s *State) ConfiguredHosts() []string {
 // 3. For unattached zmx: check save files or derive from naming convention → detached sessions
 // 4. If includeRestorePoints: add save files with no running zmx → saved sessions
 func (s *State) Sessions(includeRestorePoints bool) ([]SessionInfo, error) {
-	return s.sessionsForHost("local", includeRestorePoints)
+	return s.sessionsForHost(context.Background(), "local", includeRestorePoints)
+}
+
+// ZmxUnavailable reports whether the local zmx binary was found missing
+// during a session listing.
+func (s *State) ZmxUnavailable() bool {
+	return s.zmxMissing.Load()
 }
 
 // RemoteKmuxClient returns the remote kmux client for a given host.
@@ -120,9 +215,10 @@ func (s *State) RemoteKmuxClient(host string) *remote.Client {
 }
 
 // sessionsForHost returns sessions for a specific host.
-func (s *State) sessionsForHost(host string, includeRestorePoints bool) ([]SessionInfo, error) {
+// ctx bounds remote hosts; local queries ignore it.
+func (s *State) sessionsForHost(ctx context.Context, host string, includeRestorePoints bool) ([]SessionInfo, error) {
 	if host != "local" {
-		return s.remoteSessionsForHost(host, includeRestorePoints)
+		return s.remoteSessionsForHost(ctx, host, includeRestorePoints)
 	}
 
 	if s == nil {
@@ -142,7 +238,19 @@ func (s *State) sessionsForHost(host string, includeRestorePoints bool) ([]Sessi
 	kittyState, kittyErr := s.kitty.GetState()
 
 	// 2. Query zmx for running sessions
+	// A missing zmx binary isn't fatal: kitty-derived sessions are still listed.
+	// zmxMissing only describes the local binary, so no other host sets it.
 	zmxSessions, zmxErr := zmxClient.List()
+	if errors.Is(zmxErr, zmx.ErrNoSessions) {
+		zmxErr = nil
+	}
+	zmxMissing := host == "local" && errors.Is(zmxErr, zmx.ErrZmxUnavailable)
+	if zmxMissing {
+		s.zmxMissing.Store(true)
+		zmxErr = nil
+	}
+	// Count each pane once even if zmx lists it twice
+	zmxSessions = model.DedupeZmxSessions(zmxSessions)
 	zmxSet := make(map[string]bool)
 	for _, z := range zmxSessions {
 		zmxSet[z] = true
@@ -154,6 +262,7 @@ func (s *State) sessionsForHost(host string, includeRestorePoints bool) ([]Sessi
 	sessionWindows := make(map[string][]int)
 	sessionCWDs := make(map[string]string)
 	attachedZmx := make(map[string]bool)
+	readOnly := make(map[string]bool)
 
 	if kittyErr == nil {
 		for _, osWin := range kittyState {
@@ -174,6 +283,9 @@ func (s *State) sessionsForHost(host string, includeRestorePoints bool) ([]Sessi
 						if zmxName != "" {
 							attachedZmx[zmxName] = true
 						}
+						if win.UserVars["kmux_readonly"] != "" {
+							readOnly[sessName] = true
+						}
 					}
 				}
 			}
@@ -187,11 +299,12 @@ func (s *State) sessionsForHost(host string, includeRestorePoints bool) ([]Sessi
 	// Active sessions (have kitty windows)
 	for name, windowIDs := range sessionWindows {
 		sessions = append(sessions, SessionInfo{
-			Name:   name,
-			Host:   host,
-			Status: "active",
-			Panes:  len(windowIDs),
-			CWD:    sessionCWDs[name],
+			Name:     name,
+			Host:     host,
+			Status:   "active",
+			Panes:    len(windowIDs),
+			CWD:      sessionCWDs[name],
+			ReadOnly: readOnly[name],
 		})
 		seenSessions[name] = true
 	}
@@ -203,6 +316,7 @@ func (s *State) sessionsForHost(host string, includeRestorePoints bool) ([]Sessi
 	saveFilePanes := make(map[string]int)
 	saveFileCWDs := make(map[string]string)
 	saveFileHosts := make(map[string]string) // session name -> host from save file
+	saveFileTimes := make(map[string]time.Time)
 
 	for _, savedName := range savedSessions {
 		sess, err := s.store.LoadSession(savedName)
@@ -211,6 +325,7 @@ func (s *State) sessionsForHost(host string, includeRestorePoints bool) ([]Sessi
 		}
 		// Track the host this save file belongs to
 		saveFileHosts[savedName] = sess.Host
+		saveFileTimes[savedName] = sess.SavedAt
 		if saveFileHosts[savedName] == "" {
 			saveFileHosts[savedName] = "local"
 		}
@@ -234,6 +349,11 @@ func (s *State) sessionsForHost(host string, includeRestorePoints bool) ([]Sessi
 		saveFilePanes[savedName] = panes
 	}
 
+	// Active sessions were collected before save files were read
+	for i := range sessions {
+		sessions[i].LastSeen = saveFileTimes[sessions[i].Name]
+	}
+
 	// Find zmx sessions not attached to kitty windows -> detached
 	detachedBySession := make(map[string]int) // session name -> pane count
 	for _, zmxName := range zmxSessions {
@@ -264,11 +384,12 @@ func (s *State) sessionsForHost(host string, includeRestorePoints bool) ([]Sessi
 	for name, panes := range detachedBySession {
 		cwd := saveFileCWDs[name]
 		sessions = append(sessions, SessionInfo{
-			Name:   name,
-			Host:   host,
-			Status: "detached",
-			Panes:  panes,
-			CWD:    cwd,
+			Name:     name,
+			Host:     host,
+			Status:   "detached",
+			Panes:    panes,
+			CWD:      cwd,
+			LastSeen: saveFileTimes[name],
 		})
 		seenSessions[name] = true
 	}
@@ -291,12 +412,13 @@ func (s *State) sessionsForHost(host string, includeRestorePoints bool) ([]Sessi
 				Panes:          saveFilePanes[savedName],
 				IsRestorePoint: true,
 				CWD:            saveFileCWDs[savedName],
+				LastSeen:       saveFileTimes[savedName],
 			})
 		}
 	}
 
 	// Return error if both kitty and zmx failed (only relevant for local)
-	if host == "local" && kittyErr != nil && zmxErr != nil {
+	if host == "local" && kittyErr != nil && (zmxErr != nil || zmxMissing) {
 		return nil, kittyErr
 	}
 
@@ -305,14 +427,14 @@ func (s *State) sessionsForHost(host string, includeRestorePoints bool) ([]Sessi
 
 // remoteSessionsForHost returns sessions for a remote host using the remote kmux client.
 // Local kitty state is checked to determine which sessions are "active" from our perspective.
-func (s *State) remoteSessionsForHost(host string, includeRestorePoints bool) ([]SessionInfo, error) {
+func (s *State) remoteSessionsForHost(ctx context.Context, host string, includeRestorePoints bool) ([]SessionInfo, error) {
 	client := s.remoteKmux[host]
 	if client == nil {
 		return nil, fmt.Errorf("no kmux client for host: %s", host)
 	}
 
 	// Get sessions from remote kmux
-	remoteSessions, err := client.ListSessions()
+	remoteSessions, err := client.ListSessionsContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -388,7 +510,7 @@ func (s *State) SessionsAsync(ctx context.Context, includeRestorePoints bool) <-
 		defer close(results)
 
 		// Get local sessions first (synchronous, should be fast)
-		localSessions, err := s.sessionsForHost("local", includeRestorePoints)
+		localSessions, err := s.sessionsForHost(ctx, "local", includeRestorePoints)
 		select {
 		case results <- SessionResult{Host: "local", Sessions: localSessions, Error: err}:
 		case <-ctx.Done():
@@ -402,7 +524,7 @@ func (s *State) SessionsAsync(ctx context.Context, includeRestorePoints bool) <-
 			go func(host string) {
 				defer wg.Done()
 
-				sessions, err := s.sessionsForHost(host, false)
+				sessions, err := s.sessionsForHost(ctx, host, false)
 				select {
 				case results <- SessionResult{Host: host, Sessions: sessions, Error: err}:
 				case <-ctx.Done():
@@ -435,59 +557,58 @@ func (s *State) AllSessions(ctx context.Context, includeRestorePoints bool) ([]S
 
 // FindWindowSession returns the session info for a kitty window.
 func (s *State) FindWindowSession(windowID int) (*SessionInfo, string, string, error) {
-	kittyState, err := s.kitty.GetState()
+	// Look up just this window, then just the session's windows, instead of
+	// parsing the whole kitty tree
+	win, err := s.kitty.GetWindow(windowID)
 	if err != nil {
 		return nil, "", "", err
 	}
+	if win == nil {
+		return nil, "", "", nil // window not found
+	}
 
-	// Find the window
-	for _, osWin := range kittyState {
-		for _, tab := range osWin.Tabs {
-			for _, win := range tab.Windows {
-				if win.ID == windowID {
-					sessName := win.UserVars["kmux_session"]
-					zmxName := win.UserVars["kmux_zmx"]
-					host := win.UserVars["kmux_host"]
-					if host == "" {
-						host = "local"
-					}
-					if sessName == "" {
-						return nil, "", "", nil // not a kmux window
-					}
+	sessName := win.UserVars["kmux_session"]
+	zmxName := win.UserVars["kmux_zmx"]
+	host := win.UserVars["kmux_host"]
+	if host == "" {
+		host = "local"
+	}
+	if sessName == "" {
+		return nil, "", "", nil // not a kmux window
+	}
 
-					// Count windows for this session on this host
-					panes := 0
-					cwd := ""
-					for _, osWin2 := range kittyState {
-						for _, tab2 := range osWin2.Tabs {
-							for _, win2 := range tab2.Windows {
-								winHost := win2.UserVars["kmux_host"]
-								if winHost == "" {
-									winHost = "local"
-								}
-								if win2.UserVars["kmux_session"] == sessName && winHost == host {
-									panes++
-									if cwd == "" {
-										cwd = win2.CWD
-									}
-								}
-							}
-						}
-					}
+	sessionState, err := s.kitty.GetStateMatching(kitty.MatchUserVar("kmux_session", sessName))
+	if err != nil {
+		return nil, "", "", err
+	}
 
-					return &SessionInfo{
-						Name:   sessName,
-						Host:   host,
-						Status: "active",
-						Panes:  panes,
-						CWD:    cwd,
-					}, zmxName, host, nil
+	// Count windows for this session on this host
+	panes := 0
+	cwd := ""
+	for _, osWin := range sessionState {
+		for _, tab := range osWin.Tabs {
+			for _, win2 := range tab.Windows {
+				winHost := win2.UserVars["kmux_host"]
+				if winHost == "" {
+					winHost = "local"
+				}
+				if win2.UserVars["kmux_session"] == sessName && winHost == host {
+					panes++
+					if cwd == "" {
+						cwd = win2.CWD
+					}
 				}
 			}
 		}
 	}
 
-	return nil, "", "", nil // window not found
+	return &SessionInfo{
+		Name:   sessName,
+		Host:   host,
+		Status: "active",
+		Panes:  panes,
+		CWD:    cwd,
+	}, zmxName, host, nil
 }
 
 // GetCurrentSession returns the session for the current window (from KITTY_WINDOW_ID env).
@@ -518,7 +639,7 @@ func (s *State) SessionZmxSessionsForHost(name, host string) ([]string, error) {
 
 	zmxClient := s.ZmxClientForHost(host)
 	zmxSessions, err := zmxClient.List()
-	if err != nil {
+	if err != nil && !errors.Is(err, zmx.ErrNoSessions) {
 		return nil, err
 	}
 
@@ -571,7 +692,7 @@ func (s *State) remoteZmxSessions(name, host string) ([]string, error) {
 	// Fall back to zmx list + naming convention (remote kmux might not have save file)
 	zmxClient := s.ZmxClientForHost(host)
 	zmxSessions, err := zmxClient.List()
-	if err != nil {
+	if err != nil && !errors.Is(err, zmx.ErrNoSessions) {
 		return nil, err
 	}
 
@@ -0,0 +1,29 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewConfigError(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	if err := os.MkdirAll(filepath.Join(dir, "kmux"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "kmux", "config.toml"), []byte("[hosts\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if s, err := New(); err == nil || s != nil {
+		t.Fatalf("New() = %v, %v, want an error for a malformed config", s, err)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "kmux", "config.toml")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(); err != nil {
+		t.Errorf("New() without a config file = %v, want defaults", err)
+	}
+}
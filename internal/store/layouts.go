@@ -6,17 +6,64 @@ import (
 	"path/filepath"
 
 	"github.com/cwel/kmux/internal/config"
+	"gopkg.in/yaml.v3"
 )
 
-// LoadLayout loads a layout by name, searching user layouts first, then bundled.
-func LoadLayout(name string) (*config.Layout, error) {
-	// Search order: user layouts → bundled layouts
-	paths := []string{
+// layoutPaths returns the candidate files for a layout in search order:
+// user layouts → bundled layouts.
+func layoutPaths(name string) []string {
+	return []string{
 		filepath.Join(config.ConfigDir(), "layouts", name+".yaml"),
 		filepath.Join(config.DataDir(), "layouts", name+".yaml"),
 	}
+}
 
-	for _, path := range paths {
+// LayoutPath returns the file a layout name resolves to.
+func LayoutPath(name string) (string, error) {
+	for _, path := range layoutPaths(name) {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("layout not found: %s", name)
+}
+
+// EnsureUserLayout returns the path of the user's copy of a layout, copying
+// the bundled version into the config layouts directory first if needed.
+func EnsureUserLayout(name string) (string, error) {
+	if err := ValidateSessionName(name); err != nil {
+		return "", fmt.Errorf("invalid layout name: %q", name)
+	}
+
+	userPath := layoutPaths(name)[0]
+	if _, err := os.Stat(userPath); err == nil {
+		return userPath, nil
+	}
+
+	data, err := os.ReadFile(layoutPaths(name)[1])
+	if os.IsNotExist(err) {
+		content, ok := BundledLayouts[name]
+		if !ok {
+			return "", fmt.Errorf("layout not found: %s", name)
+		}
+		data, err = []byte(content), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("read bundled layout: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(userPath), 0755); err != nil {
+		return "", fmt.Errorf("create layouts dir: %w", err)
+	}
+	if err := os.WriteFile(userPath, data, 0644); err != nil {
+		return "", fmt.Errorf("write layout: %w", err)
+	}
+	return userPath, nil
+}
+
+// LoadLayout loads a layout by name, searching user layouts first, then bundled.
+func LoadLayout(name string) (*config.Layout, error) {
+	for _, path := range layoutPaths(name) {
 		data, err := os.ReadFile(path)
 		if err != nil {
 			if os.IsNotExist(err) {
@@ -40,6 +87,36 @@ func LoadLayout(name string) (*config.Layout, error) {
 	return nil, fmt.Errorf("layout not found: %s", name)
 }
 
+// SaveLayout validates a layout and writes it to the user layouts directory
+// as <name>.yaml. Existing layouts are only replaced when overwrite is set.
+func SaveLayout(layout *config.Layout, overwrite bool) (string, error) {
+	if err := layout.Validate(); err != nil {
+		return "", fmt.Errorf("validate layout: %w", err)
+	}
+	if err := ValidateSessionName(layout.Name); err != nil {
+		return "", fmt.Errorf("invalid layout name: %q", layout.Name)
+	}
+
+	dir := filepath.Join(config.ConfigDir(), "layouts")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("create layouts dir: %w", err)
+	}
+
+	path := filepath.Join(dir, layout.Name+".yaml")
+	if _, err := os.Stat(path); err == nil && !overwrite {
+		return "", fmt.Errorf("layout already exists: %s", path)
+	}
+
+	data, err := yaml.Marshal(layout)
+	if err != nil {
+		return "", fmt.Errorf("marshal layout: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("write layout: %w", err)
+	}
+	return path, nil
+}
+
 // ListLayouts returns available layout names.
 func ListLayouts() ([]string, error) {
 	seen := make(map[string]bool)
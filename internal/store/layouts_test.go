@@ -150,3 +150,61 @@ func TestInstallBundledLayouts(t *testing.T) {
 		t.Error("InstallBundledLayouts() should not overwrite existing files")
 	}
 }
+
+func TestSaveLayout(t *testing.T) {
+	configDir := t.TempDir()
+	os.Setenv("KMUX_CONFIG_DIR", configDir)
+	defer os.Unsetenv("KMUX_CONFIG_DIR")
+
+	layout := &config.Layout{
+		Name: "captured",
+		Tabs: []config.LayoutTab{{Title: "main", Layout: "tall", Bias: 60, Panes: []string{"nvim", ""}}},
+	}
+	if _, err := SaveLayout(layout, false); err != nil {
+		t.Fatalf("SaveLayout() error = %v", err)
+	}
+	if _, err := SaveLayout(layout, false); err == nil {
+		t.Error("expected error saving over existing layout without overwrite")
+	}
+
+	loaded, err := LoadLayout("captured")
+	if err != nil {
+		t.Fatalf("LoadLayout() error = %v", err)
+	}
+	if loaded.Tabs[0].Bias != 60 || len(loaded.Tabs[0].Panes) != 2 || loaded.Tabs[0].Panes[0] != "nvim" {
+		t.Errorf("round-tripped layout = %+v", loaded.Tabs[0])
+	}
+}
+
+func TestEnsureUserLayout(t *testing.T) {
+	configDir := t.TempDir()
+	dataDir := t.TempDir()
+	t.Setenv("KMUX_CONFIG_DIR", configDir)
+	t.Setenv("KMUX_DATA_DIR", dataDir)
+
+	// Not installed: falls back to the embedded bundled layout
+	path, err := EnsureUserLayout("tall")
+	if err != nil {
+		t.Fatalf("EnsureUserLayout() error = %v", err)
+	}
+	if want := filepath.Join(configDir, "layouts", "tall.yaml"); path != want {
+		t.Errorf("path = %q, want %q", path, want)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != BundledLayouts["tall"] {
+		t.Errorf("user copy = %q, %v; want bundled content", data, err)
+	}
+
+	// An existing user copy is left alone
+	os.WriteFile(path, []byte("edited"), 0644)
+	if _, err := EnsureUserLayout("tall"); err != nil {
+		t.Fatalf("EnsureUserLayout() second call error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "edited" {
+		t.Errorf("user copy = %q, want it untouched", data)
+	}
+
+	if _, err := EnsureUserLayout("nonexistent"); err == nil {
+		t.Error("EnsureUserLayout() expected error for unknown layout")
+	}
+}
@@ -2,8 +2,10 @@ package store
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 )
 
@@ -33,15 +35,31 @@ func init() {
 }
 
 // LoadOwnership loads the ownership mapping from disk.
+// If the file is corrupt (e.g. truncated by a power loss), the backup kept
+// by SaveOwnership is used instead so existing mappings aren't abandoned.
 func LoadOwnership() (*Ownership, error) {
 	ownershipMu.Lock()
 	defer ownershipMu.Unlock()
 
-	data, err := os.ReadFile(ownershipPath)
+	o, err := readOwnership(ownershipPath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return &Ownership{ZmxToSession: make(map[string]string)}, nil
 		}
+		backup, bakErr := readOwnership(ownershipPath + ".bak")
+		if bakErr != nil {
+			return nil, err
+		}
+		fmt.Fprintf(os.Stderr, "kmux: %s unreadable (%v), using backup\n", ownershipPath, err)
+		return backup, nil
+	}
+	return o, nil
+}
+
+// readOwnership reads and parses one ownership file.
+func readOwnership(path string) (*Ownership, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
 		return nil, err
 	}
 
@@ -56,6 +74,8 @@ func LoadOwnership() (*Ownership, error) {
 }
 
 // SaveOwnership saves the ownership mapping to disk.
+// The previous file is kept as a .bak when it still parses, as a fallback
+// for LoadOwnership.
 func SaveOwnership(o *Ownership) error {
 	ownershipMu.Lock()
 	defer ownershipMu.Unlock()
@@ -75,6 +95,13 @@ func SaveOwnership(o *Ownership) error {
 	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
 		return err
 	}
+
+	// Only rotate a good file into the backup, never a corrupt one
+	if prev, err := os.ReadFile(ownershipPath); err == nil && json.Valid(prev) {
+		if err := os.WriteFile(ownershipPath+".bak", prev, 0644); err != nil {
+			return err
+		}
+	}
 	return os.Rename(tmpPath, ownershipPath)
 }
 
@@ -88,6 +115,55 @@ func GetSessionForZmx(zmxName string) string {
 	return o.ZmxToSession[zmxName]
 }
 
+// GetZmxSessionsForSession returns the zmx session names owned by a session.
+func GetZmxSessionsForSession(sessionName string) []string {
+	o, err := LoadOwnership()
+	if err != nil {
+		return nil
+	}
+	var zmxNames []string
+	for zmxName, sessName := range o.ZmxToSession {
+		if sessName == sessionName {
+			zmxNames = append(zmxNames, zmxName)
+		}
+	}
+	sort.Strings(zmxNames)
+	return zmxNames
+}
+
+// SetZmxOwnership records zmx session name -> session name entries.
+func SetZmxOwnership(entries map[string]string) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	o, err := LoadOwnership()
+	if err != nil {
+		return err
+	}
+	for zmxName, sessName := range entries {
+		o.ZmxToSession[zmxName] = sessName
+	}
+	return SaveOwnership(o)
+}
+
+// RenameZmxOwnership moves ownership entries to renamed zmx sessions.
+// renamed maps old zmx names to new zmx names.
+func RenameZmxOwnership(renamed map[string]string) error {
+	o, err := LoadOwnership()
+	if err != nil {
+		return err
+	}
+
+	for oldZmx, newZmx := range renamed {
+		if sessName, ok := o.ZmxToSession[oldZmx]; ok {
+			delete(o.ZmxToSession, oldZmx)
+			o.ZmxToSession[newZmx] = sessName
+		}
+	}
+
+	return SaveOwnership(o)
+}
+
 // RenameSessionOwnership updates all zmx mappings from oldName to newName.
 func RenameSessionOwnership(oldName, newName string) error {
 	o, err := LoadOwnership()
@@ -104,3 +180,32 @@ func RenameSessionOwnership(oldName, newName string) error {
 
 	return SaveOwnership(o)
 }
+
+// PruneOwnership drops entries whose zmx session is not in liveZmx, e.g.
+// sessions that exited or were killed outside kmux. Returns the pruned zmx
+// names, sorted.
+func PruneOwnership(liveZmx []string) ([]string, error) {
+	o, err := LoadOwnership()
+	if err != nil {
+		return nil, err
+	}
+
+	live := make(map[string]bool, len(liveZmx))
+	for _, zmxName := range liveZmx {
+		live[zmxName] = true
+	}
+
+	var pruned []string
+	for zmxName := range o.ZmxToSession {
+		if !live[zmxName] {
+			delete(o.ZmxToSession, zmxName)
+			pruned = append(pruned, zmxName)
+		}
+	}
+	if len(pruned) == 0 {
+		return nil, nil
+	}
+	sort.Strings(pruned)
+
+	return pruned, SaveOwnership(o)
+}
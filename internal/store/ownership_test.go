@@ -0,0 +1,73 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadOwnershipFallsBackToBackup(t *testing.T) {
+	orig := ownershipPath
+	ownershipPath = filepath.Join(t.TempDir(), "zmx-ownership.json")
+	t.Cleanup(func() { ownershipPath = orig })
+
+	// Two saves leave the first mapping in the backup
+	if err := SaveOwnership(&Ownership{ZmxToSession: map[string]string{"foo.0.0": "bar"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveOwnership(&Ownership{ZmxToSession: map[string]string{"foo.0.0": "baz"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Truncate the primary mid-write
+	if err := os.WriteFile(ownershipPath, []byte(`{"zmx_to_sess`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	o, err := LoadOwnership()
+	if err != nil {
+		t.Fatalf("LoadOwnership() error = %v, want backup", err)
+	}
+	if got := o.ZmxToSession["foo.0.0"]; got != "bar" {
+		t.Errorf("foo.0.0 -> %q, want %q from backup", got, "bar")
+	}
+
+	// A save over the corrupt primary must not clobber the good backup
+	if err := SaveOwnership(o); err != nil {
+		t.Fatal(err)
+	}
+	backup, err := readOwnership(ownershipPath + ".bak")
+	if err != nil || backup.ZmxToSession["foo.0.0"] != "bar" {
+		t.Errorf("backup = %+v, %v; want the last good mapping", backup, err)
+	}
+}
+
+func TestPruneOwnership(t *testing.T) {
+	orig := ownershipPath
+	ownershipPath = filepath.Join(t.TempDir(), "zmx-ownership.json")
+	t.Cleanup(func() { ownershipPath = orig })
+
+	if err := SetZmxOwnership(map[string]string{
+		"foo.0.0": "bar",
+		"foo.0.1": "bar",
+		"old.0.0": "gone",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	pruned, err := PruneOwnership([]string{"foo.0.0", "unowned.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pruned) != 2 || pruned[0] != "foo.0.1" || pruned[1] != "old.0.0" {
+		t.Errorf("pruned = %v, want [foo.0.1 old.0.0]", pruned)
+	}
+
+	o, err := LoadOwnership()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(o.ZmxToSession) != 1 || o.ZmxToSession["foo.0.0"] != "bar" {
+		t.Errorf("ownership = %v, want only foo.0.0 -> bar", o.ZmxToSession)
+	}
+}
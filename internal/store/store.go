@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/cwel/kmux/internal/model"
 )
@@ -66,6 +67,7 @@ func (s *Store) SaveSession(session *model.Session) error {
 		return fmt.Errorf("create sessions dir: %w", err)
 	}
 
+	session.SchemaVersion = model.CurrentSchemaVersion
 	data, err := json.MarshalIndent(session, "", "  ")
 	if err != nil {
 		return fmt.Errorf("marshal session: %w", err)
@@ -100,10 +102,53 @@ func (s *Store) LoadSession(name string) (*model.Session, error) {
 	if err := json.Unmarshal(data, &session); err != nil {
 		return nil, fmt.Errorf("unmarshal session: %w", err)
 	}
+	migrateSession(&session)
 
 	return &session, nil
 }
 
+// TouchSession sets a save file's timestamp to at, so the session sorts as
+// recently seen. A missing save file is reported as fs.ErrNotExist.
+func (s *Store) TouchSession(name string, at time.Time) error {
+	session, err := s.LoadSession(name)
+	if err != nil {
+		return err
+	}
+	session.SavedAt = at
+	return s.SaveSession(session)
+}
+
+// migrateSession upgrades a session loaded from an older save file format
+// in place. The upgraded form is persisted on the next save.
+func migrateSession(session *model.Session) {
+	if session.SchemaVersion >= model.CurrentSchemaVersion {
+		return
+	}
+
+	// v0 -> v1: Host and per-window ZmxName were added
+	if session.Host == "" {
+		session.Host = "local"
+	}
+	for tabIdx := range session.Tabs {
+		for winIdx := range session.Tabs[tabIdx].Windows {
+			win := &session.Tabs[tabIdx].Windows[winIdx]
+			if win.ZmxName == "" {
+				// Old files always used the naming convention
+				win.ZmxName = session.ZmxSessionName(tabIdx, winIdx)
+			}
+		}
+	}
+	if len(session.ZmxSessions) == 0 {
+		for _, tab := range session.Tabs {
+			for _, win := range tab.Windows {
+				session.ZmxSessions = append(session.ZmxSessions, win.ZmxName)
+			}
+		}
+	}
+
+	session.SchemaVersion = model.CurrentSchemaVersion
+}
+
 // ListSessions returns the names of all saved sessions.
 func (s *Store) ListSessions() ([]string, error) {
 	dir := s.sessionsDir()
@@ -166,3 +211,136 @@ func (s *Store) RenameSession(oldName, newName string) error {
 	// Remove old file
 	return os.Remove(oldPath)
 }
+
+// StaleSession describes a save file eligible for pruning.
+type StaleSession struct {
+	Name   string
+	Reason string
+}
+
+// StaleSessions returns save files older than maxAge whose first window's
+// working directory no longer exists on disk. Sessions named in live (those
+// with running zmx sessions or kitty windows) are never stale.
+func (s *Store) StaleSessions(maxAge time.Duration, live map[string]bool) ([]StaleSession, error) {
+	names, err := s.ListSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	var stale []StaleSession
+	for _, name := range names {
+		if live[name] {
+			continue
+		}
+		sess, err := s.LoadSession(name)
+		if err != nil {
+			continue
+		}
+		if sess.SavedAt.After(cutoff) {
+			continue
+		}
+		if len(sess.Tabs) == 0 || len(sess.Tabs[0].Windows) == 0 {
+			continue
+		}
+		cwd := sess.Tabs[0].Windows[0].CWD
+		if cwd == "" {
+			continue
+		}
+		if _, err := os.Stat(cwd); !os.IsNotExist(err) {
+			continue
+		}
+		stale = append(stale, StaleSession{
+			Name:   name,
+			Reason: fmt.Sprintf("saved %s, %s no longer exists", sess.SavedAt.Format("2006-01-02"), cwd),
+		})
+	}
+	return stale, nil
+}
+
+// PruneStale deletes the save files reported by StaleSessions and returns them.
+func (s *Store) PruneStale(maxAge time.Duration, live map[string]bool) ([]StaleSession, error) {
+	stale, err := s.StaleSessions(maxAge, live)
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []StaleSession
+	for _, st := range stale {
+		if err := s.DeleteSession(st.Name); err != nil {
+			return pruned, err
+		}
+		if err := s.ForgetLayout(st.Name); err != nil {
+			return pruned, err
+		}
+		pruned = append(pruned, st)
+	}
+	return pruned, nil
+}
+
+// layoutRecallPath returns the path to the session → layout template map.
+// It outlives save files, so a killed session is recreated with the same
+// layout. Only prune (or ForgetLayout) drops a session's entry.
+func (s *Store) layoutRecallPath() string {
+	return filepath.Join(s.baseDir, "layout-recall.json")
+}
+
+// loadLayoutRecall reads the layout recall map, empty if there is none.
+func (s *Store) loadLayoutRecall() map[string]string {
+	recall := make(map[string]string)
+	if data, err := os.ReadFile(s.layoutRecallPath()); err == nil {
+		json.Unmarshal(data, &recall)
+	}
+	return recall
+}
+
+// RememberLayout records the layout template a session was created with.
+func (s *Store) RememberLayout(name, layout string) error {
+	recall := s.loadLayoutRecall()
+	if recall[name] == layout {
+		return nil
+	}
+	recall[name] = layout
+	return s.writeLayoutRecall(recall)
+}
+
+// ForgetLayout drops the layout template recorded for a session.
+func (s *Store) ForgetLayout(name string) error {
+	recall := s.loadLayoutRecall()
+	if _, ok := recall[name]; !ok {
+		return nil
+	}
+	delete(recall, name)
+	return s.writeLayoutRecall(recall)
+}
+
+// writeLayoutRecall atomically replaces the layout recall map.
+func (s *Store) writeLayoutRecall(recall map[string]string) error {
+	if err := os.MkdirAll(s.baseDir, 0755); err != nil {
+		return fmt.Errorf("create data dir: %w", err)
+	}
+	data, err := json.MarshalIndent(recall, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal layout recall: %w", err)
+	}
+	path := s.layoutRecallPath()
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// RecalledLayout returns the layout template a session was last created with,
+// or empty string if none was recorded.
+func (s *Store) RecalledLayout(name string) string {
+	data, err := os.ReadFile(s.layoutRecallPath())
+	if err != nil {
+		return ""
+	}
+	var recall map[string]string
+	if err := json.Unmarshal(data, &recall); err != nil {
+		return ""
+	}
+	return recall[name]
+}
@@ -1,6 +1,8 @@
 package store
 
 import (
+	"errors"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"testing"
@@ -84,6 +86,30 @@ func TestListSessions(t *testing.T) {
 	}
 }
 
+func TestTouchSession(t *testing.T) {
+	s := New(t.TempDir())
+
+	if err := s.TouchSession("missing", time.Now()); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("TouchSession(missing) = %v, want fs.ErrNotExist", err)
+	}
+
+	old := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	if err := s.SaveSession(&model.Session{Name: "work", Host: "local", SavedAt: old}); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().Truncate(time.Second)
+	if err := s.TouchSession("work", now); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := s.LoadSession("work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.SavedAt.Equal(now) {
+		t.Errorf("SavedAt = %v, want %v", loaded.SavedAt, now)
+	}
+}
+
 func TestRenameSession(t *testing.T) {
 	dir := t.TempDir()
 	s := New(dir)
@@ -113,3 +139,153 @@ func TestRenameSession(t *testing.T) {
 		t.Errorf("expected name 'new', got %q", loaded.Name)
 	}
 }
+
+func TestPruneStale(t *testing.T) {
+	dir := t.TempDir()
+	s := New(dir)
+	old := time.Now().Add(-60 * 24 * time.Hour)
+
+	sessions := []*model.Session{
+		// Old and project dir gone: pruned
+		{Name: "gone", SavedAt: old, Tabs: []model.Tab{{Windows: []model.Window{{CWD: filepath.Join(dir, "missing")}}}}},
+		// Old but project dir still exists: kept
+		{Name: "exists", SavedAt: old, Tabs: []model.Tab{{Windows: []model.Window{{CWD: dir}}}}},
+		// Recent with missing dir: kept
+		{Name: "recent", SavedAt: time.Now(), Tabs: []model.Tab{{Windows: []model.Window{{CWD: filepath.Join(dir, "missing")}}}}},
+		// Old with missing dir, but still running: kept
+		{Name: "running", SavedAt: old, Tabs: []model.Tab{{Windows: []model.Window{{CWD: filepath.Join(dir, "missing")}}}}},
+	}
+	live := map[string]bool{"running": true}
+	for _, sess := range sessions {
+		if err := s.SaveSession(sess); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.RememberLayout("gone", "dev"); err != nil {
+		t.Fatal(err)
+	}
+
+	stale, err := s.StaleSessions(30*24*time.Hour, live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stale) != 1 || stale[0].Name != "gone" {
+		t.Fatalf("StaleSessions() = %v, want [gone]", stale)
+	}
+
+	pruned, err := s.PruneStale(30*24*time.Hour, live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pruned) != 1 {
+		t.Fatalf("PruneStale() pruned %d, want 1", len(pruned))
+	}
+	if got := s.RecalledLayout("gone"); got != "" {
+		t.Errorf("RecalledLayout(gone) = %q after prune, want empty", got)
+	}
+	names, _ := s.ListSessions()
+	if len(names) != 3 {
+		t.Errorf("expected 3 remaining sessions, got %v", names)
+	}
+}
+
+func TestLayoutRecall(t *testing.T) {
+	s := New(t.TempDir())
+
+	if got := s.RecalledLayout("proj"); got != "" {
+		t.Errorf("RecalledLayout() = %q, want empty", got)
+	}
+	if err := s.RememberLayout("proj", "dev"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RememberLayout("other", "ide"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Survives deleting the save file
+	s.DeleteSession("proj")
+	if got := s.RecalledLayout("proj"); got != "dev" {
+		t.Errorf("RecalledLayout() = %q, want %q", got, "dev")
+	}
+	if got := s.RecalledLayout("other"); got != "ide" {
+		t.Errorf("RecalledLayout() = %q, want %q", got, "ide")
+	}
+
+	if err := s.ForgetLayout("proj"); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.RecalledLayout("proj"); got != "" {
+		t.Errorf("RecalledLayout() = %q after ForgetLayout, want empty", got)
+	}
+	if got := s.RecalledLayout("other"); got != "ide" {
+		t.Errorf("RecalledLayout(other) = %q, want %q", got, "ide")
+	}
+}
+
+func TestLoadSessionMigratesLegacyFormat(t *testing.T) {
+	// testdata/sessions/legacy.json predates schema_version, host and zmx_name
+	s := New("testdata")
+
+	sess, err := s.LoadSession("legacy")
+	if err != nil {
+		t.Fatalf("LoadSession failed: %v", err)
+	}
+
+	if sess.SchemaVersion != model.CurrentSchemaVersion {
+		t.Errorf("SchemaVersion = %d, want %d", sess.SchemaVersion, model.CurrentSchemaVersion)
+	}
+	if sess.Host != "local" {
+		t.Errorf("Host = %q, want %q", sess.Host, "local")
+	}
+	if got := sess.Tabs[0].Windows[1].ZmxName; got != "legacy.0.1" {
+		t.Errorf("Tabs[0].Windows[1].ZmxName = %q, want %q", got, "legacy.0.1")
+	}
+	want := []string{"legacy.0.0", "legacy.0.1", "legacy.1.0"}
+	if len(sess.ZmxSessions) != len(want) {
+		t.Fatalf("ZmxSessions = %v, want %v", sess.ZmxSessions, want)
+	}
+	for i := range want {
+		if sess.ZmxSessions[i] != want[i] {
+			t.Errorf("ZmxSessions[%d] = %q, want %q", i, sess.ZmxSessions[i], want[i])
+		}
+	}
+}
+
+func TestLoadSessionBackfillsZmxSessionsFromWindows(t *testing.T) {
+	// testdata/sessions/renamed.json was saved after a rename but before
+	// zmx_sessions existed: the windows keep their original zmx names
+	s := New("testdata")
+
+	sess, err := s.LoadSession("renamed")
+	if err != nil {
+		t.Fatalf("LoadSession failed: %v", err)
+	}
+
+	want := []string{"oldname.0.0", "oldname.0.1"}
+	if len(sess.ZmxSessions) != len(want) {
+		t.Fatalf("ZmxSessions = %v, want %v", sess.ZmxSessions, want)
+	}
+	for i := range want {
+		if sess.ZmxSessions[i] != want[i] {
+			t.Errorf("ZmxSessions[%d] = %q, want %q", i, sess.ZmxSessions[i], want[i])
+		}
+	}
+	if got := sess.Tabs[0].Windows[0].ZmxName; got != "oldname.0.0" {
+		t.Errorf("Tabs[0].Windows[0].ZmxName = %q, want %q", got, "oldname.0.0")
+	}
+}
+
+func TestSaveSessionWritesSchemaVersion(t *testing.T) {
+	s := New(t.TempDir())
+	if err := s.SaveSession(&model.Session{Name: "fresh", Host: "local"}); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := s.LoadSession("fresh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.SchemaVersion != model.CurrentSchemaVersion {
+		t.Errorf("SchemaVersion = %d, want %d", loaded.SchemaVersion, model.CurrentSchemaVersion)
+	}
+}
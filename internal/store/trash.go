@@ -0,0 +1,157 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cwel/kmux/internal/model"
+)
+
+// Bundle is the portable save file format written by 'kmux session export'
+// and kept in the trash by 'kmux kill --all'.
+type Bundle struct {
+	Sessions  []*model.Session  `json:"sessions"`
+	Ownership map[string]string `json:"ownership,omitempty"` // zmx name -> session name
+}
+
+// ExportBundle bundles the named save files with their zmx ownership.
+func (s *Store) ExportBundle(names []string) (*Bundle, error) {
+	bundle := &Bundle{Ownership: make(map[string]string)}
+	for _, name := range names {
+		session, err := s.LoadSession(name)
+		if err != nil {
+			return nil, fmt.Errorf("session not found: %s", name)
+		}
+		bundle.Sessions = append(bundle.Sessions, session)
+		for _, zmxName := range GetZmxSessionsForSession(name) {
+			bundle.Ownership[zmxName] = name
+		}
+	}
+	return bundle, nil
+}
+
+// trashIDFormat names trash entries by the time they were written.
+const trashIDFormat = "20060102-150405"
+
+// TrashEntry describes a bundle in the trash.
+type TrashEntry struct {
+	ID       string    `json:"id"`
+	Time     time.Time `json:"time"`
+	Sessions []string  `json:"sessions"`
+}
+
+// trashDir returns the directory holding trashed bundles.
+func (s *Store) trashDir() string {
+	return filepath.Join(s.baseDir, "trash")
+}
+
+// WriteTrash stores bundle in the trash and returns its ID.
+func (s *Store) WriteTrash(bundle *Bundle, at time.Time) (string, error) {
+	dir := s.trashDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("create trash dir: %w", err)
+	}
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal bundle: %w", err)
+	}
+
+	// Two kills within a second get distinct IDs
+	base := at.Format(trashIDFormat)
+	for n := 1; ; n++ {
+		id := base
+		if n > 1 {
+			id += "-" + strconv.Itoa(n)
+		}
+		f, err := os.OpenFile(filepath.Join(dir, id+".json"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("create trash file: %w", err)
+		}
+		_, err = f.Write(data)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return "", fmt.Errorf("write trash file: %w", err)
+		}
+		return id, nil
+	}
+}
+
+// LoadTrash reads a trashed bundle by ID.
+func (s *Store) LoadTrash(id string) (*Bundle, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return nil, fmt.Errorf("invalid trash id: %q", id)
+	}
+	data, err := os.ReadFile(filepath.Join(s.trashDir(), id+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("read trash %s: %w", id, err)
+	}
+	var bundle Bundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("parse trash %s: %w", id, err)
+	}
+	return &bundle, nil
+}
+
+// ListTrash returns the trashed bundles, newest first.
+func (s *Store) ListTrash() ([]TrashEntry, error) {
+	entries, err := os.ReadDir(s.trashDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read trash dir: %w", err)
+	}
+
+	var trash []TrashEntry
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if e.IsDir() || !ok {
+			continue
+		}
+		at, err := time.ParseInLocation(trashIDFormat, id[:min(len(id), len(trashIDFormat))], time.Local)
+		if err != nil {
+			continue
+		}
+		entry := TrashEntry{ID: id, Time: at}
+		if bundle, err := s.LoadTrash(id); err == nil {
+			for _, sess := range bundle.Sessions {
+				if sess != nil {
+					entry.Sessions = append(entry.Sessions, sess.Name)
+				}
+			}
+		}
+		trash = append(trash, entry)
+	}
+	sort.Slice(trash, func(i, j int) bool { return trash[i].ID > trash[j].ID })
+	return trash, nil
+}
+
+// PruneTrash deletes trashed bundles older than maxAge and returns their IDs.
+func (s *Store) PruneTrash(maxAge time.Duration, now time.Time) ([]string, error) {
+	trash, err := s.ListTrash()
+	if err != nil {
+		return nil, err
+	}
+	var pruned []string
+	for _, entry := range trash {
+		if now.Sub(entry.Time) <= maxAge {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.trashDir(), entry.ID+".json")); err != nil {
+			return pruned, fmt.Errorf("remove trash %s: %w", entry.ID, err)
+		}
+		pruned = append(pruned, entry.ID)
+	}
+	return pruned, nil
+}
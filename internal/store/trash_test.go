@@ -0,0 +1,71 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cwel/kmux/internal/model"
+)
+
+func TestTrash(t *testing.T) {
+	orig := ownershipPath
+	ownershipPath = filepath.Join(t.TempDir(), "zmx-ownership.json")
+	t.Cleanup(func() { ownershipPath = orig })
+
+	s := New(t.TempDir())
+	if err := s.SaveSession(&model.Session{Name: "work", Host: "local"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetZmxOwnership(map[string]string{"old.0.0": "work"}); err != nil {
+		t.Fatal(err)
+	}
+
+	bundle, err := s.ExportBundle([]string{"work"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bundle.Sessions) != 1 || bundle.Ownership["old.0.0"] != "work" {
+		t.Fatalf("bundle = %+v, want work with its ownership", bundle)
+	}
+
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	first, err := s.WriteTrash(bundle, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.WriteTrash(&Bundle{}, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != "20260301-120000" || second != "20260301-120000-2" {
+		t.Errorf("trash IDs = %q, %q; want distinct IDs for the same second", first, second)
+	}
+
+	trash, err := s.ListTrash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trash) != 2 || trash[0].ID != second || len(trash[1].Sessions) != 1 || trash[1].Sessions[0] != "work" {
+		t.Errorf("ListTrash() = %+v", trash)
+	}
+
+	loaded, err := s.LoadTrash(first)
+	if err != nil || len(loaded.Sessions) != 1 || loaded.Sessions[0].Name != "work" {
+		t.Errorf("LoadTrash() = %+v, %v", loaded, err)
+	}
+	if _, err := s.LoadTrash("../sessions/work"); err == nil {
+		t.Error("LoadTrash should reject paths")
+	}
+
+	pruned, err := s.PruneTrash(24*time.Hour, at.Add(48*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pruned) != 2 {
+		t.Errorf("pruned = %v, want both entries", pruned)
+	}
+	if trash, _ := s.ListTrash(); len(trash) != 0 {
+		t.Errorf("ListTrash() after prune = %+v, want empty", trash)
+	}
+}
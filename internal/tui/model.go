@@ -2,17 +2,23 @@ package tui
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/cwel/kmux/internal/config"
+	"github.com/cwel/kmux/internal/manager"
+	"github.com/cwel/kmux/internal/model"
 	"github.com/cwel/kmux/internal/project"
+	"github.com/cwel/kmux/internal/remote"
 	"github.com/cwel/kmux/internal/state"
 	"github.com/cwel/kmux/internal/store"
 	"github.com/sahilm/fuzzy"
@@ -35,6 +41,7 @@ type Item struct {
 	PaneCount int    // only for sessions
 	Status    string // only for sessions: "active", "detached", "saved"
 	CWD       string // for sessions
+	ReadOnly  bool   // session attached read-only
 }
 
 // Model is the bubbletea model for the TUI.
@@ -48,6 +55,7 @@ type Model struct {
 	filterMode    bool
 	renameMode    bool
 	renameInput   textinput.Model
+	renameErr     string // validation error for the new name, shown below the input
 	showHelp      bool
 	confirmKill   bool
 	confirmIgnore bool // confirm adding project to ignore list
@@ -58,10 +66,19 @@ type Model struct {
 	action        string // "attach", "kill", or "create" - set when exiting to perform action
 	state         *state.State
 	cfg           *config.Config
+	indicators    map[string]lipgloss.Style // session status -> glyph
 
 	// Host loading state
-	loadingHosts map[string]bool // hosts currently being queried
-	hostErrors   map[string]error
+	loadingHosts   map[string]bool // hosts currently being queried
+	hostErrors     map[string]error
+	showHostErrors bool          // expand host error messages below the status line
+	hostTimeout    time.Duration // per-host query limit before it's shown as errored
+	hostFilter     string        // only load this host ("" = all hosts)
+
+	// One-time footer warning (e.g. zmx not installed), cleared on next key
+	warning    string
+	warned     bool // zmx warning shown
+	dirsWarned bool // project directories warning shown
 
 	// Launch mode (layout selection modal)
 	launchMode      bool
@@ -71,6 +88,10 @@ type Model struct {
 	launchNameFocus bool // true = name field focused, false = layout list focused
 	launchLayout    string
 	launchName      string
+	launchTemplate  *config.Layout    // selected layout whose pane commands are being edited
+	launchPanes     []textinput.Model // one command input per pane of launchTemplate, shown after [e]
+	launchPaneFocus int               // focused pane input, -1 = none
+	launchEdited    *config.Layout    // launchTemplate with edited commands, nil if unchanged
 
 	// Host selection for new sessions
 	hostMode       bool
@@ -82,6 +103,9 @@ type Model struct {
 	yaziPath string // path selected from yazi
 }
 
+// DefaultHostTimeout bounds each remote host query made by the TUI.
+const DefaultHostTimeout = 10 * time.Second
+
 // New creates a new TUI model.
 func New(s *state.State, cfg *config.Config) Model {
 	ti := textinput.New()
@@ -106,15 +130,43 @@ func New(s *state.State, cfg *config.Config) Model {
 		filterInput:     ti,
 		renameInput:     ri,
 		launchNameInput: li,
+		launchPaneFocus: -1,
 		state:           s,
 		cfg:             cfg,
+		indicators:      statusIndicators(cfg),
 		loadingHosts:    make(map[string]bool),
 		hostErrors:      make(map[string]error),
+		hostTimeout:     DefaultHostTimeout,
 		hostList:        hostList,
 		selectedHost:    "local",
 	}
 }
 
+// WithHostTimeout returns the model with remote host queries limited to d.
+// Non-positive values keep the default.
+func (m Model) WithHostTimeout(d time.Duration) Model {
+	if d > 0 {
+		m.hostTimeout = d
+	}
+	return m
+}
+
+// WithHost returns the model scoped to a single host: only that host's
+// sessions are loaded, and new sessions default to it. Projects are only
+// scanned for "local", since project directories are local paths.
+func (m Model) WithHost(host string) Model {
+	if host != "" {
+		m.hostFilter = host
+		m.selectedHost = host
+	}
+	return m
+}
+
+// loadsHost reports whether the model loads sessions for host.
+func (m Model) loadsHost(host string) bool {
+	return m.hostFilter == "" || m.hostFilter == host
+}
+
 // Init implements tea.Model.
 func (m Model) Init() tea.Cmd {
 	return m.loadDataAsync
@@ -122,6 +174,11 @@ func (m Model) Init() tea.Cmd {
 
 // loadDataAsync starts async loading of sessions from all hosts.
 func (m Model) loadDataAsync() tea.Msg {
+	if !m.loadsHost("local") {
+		// Scoped to a remote host: nothing local to show, go straight to remote loading
+		return dataLoadedMsg{host: "local"}
+	}
+
 	// First, load local data synchronously for immediate display
 	sessions, err := m.state.Sessions(true)
 	if err != nil {
@@ -143,13 +200,16 @@ func (m Model) loadDataAsync() tea.Msg {
 			PaneCount: s.Panes,
 			Status:    s.Status,
 			CWD:       s.CWD,
+			ReadOnly:  s.ReadOnly,
 		})
 	}
 
 	// Scan for projects
 	var projectItems []Item
+	var badProjectDirs []error
 	if m.cfg != nil {
 		scanner := project.NewScanner(m.cfg)
+		badProjectDirs = scanner.CheckDirs()
 		projects := scanner.Scan()
 		// Filter out projects that already have sessions
 		projects = project.FilterExisting(projects, sessionNames)
@@ -162,7 +222,22 @@ func (m Model) loadDataAsync() tea.Msg {
 		}
 	}
 
-	return dataLoadedMsg{sessions: sessionItems, projects: projectItems, host: "local"}
+	return dataLoadedMsg{
+		sessions:   sessionItems,
+		projects:   projectItems,
+		host:       "local",
+		zmxMissing: m.state.ZmxUnavailable(),
+		badDirs:    badProjectDirs,
+	}
+}
+
+// projectDirsWarning summarizes configured project directories that can't be
+// scanned for the footer.
+func projectDirsWarning(problems []error) string {
+	if len(problems) == 1 {
+		return "project directory " + problems[0].Error()
+	}
+	return fmt.Sprintf("%d project directories can't be scanned (see kmux projects scan)", len(problems))
 }
 
 // startRemoteLoading kicks off background queries to remote hosts.
@@ -175,6 +250,9 @@ func (m Model) startRemoteLoading() tea.Cmd {
 	// Return a batch of commands, one per host
 	var cmds []tea.Cmd
 	for _, host := range hosts {
+		if !m.loadsHost(host) {
+			continue
+		}
 		h := host // capture for closure
 		cmds = append(cmds, func() tea.Msg {
 			return hostLoadingMsg{host: h}
@@ -187,54 +265,57 @@ func (m Model) startRemoteLoading() tea.Cmd {
 // loadHostSessions loads sessions for a specific remote host.
 func (m Model) loadHostSessions(host string) tea.Cmd {
 	return func() tea.Msg {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), m.hostTimeout)
 		defer cancel()
 
-		// Query just this host
-		zmxClient := m.state.ZmxClientForHost(host)
-		zmxSessions, err := zmxClient.List()
+		// Query just this host (cached briefly; an explicit refresh invalidates it)
+		zmxSessions, err := m.state.CachedZmxListContext(ctx, host, false)
+		if errors.Is(err, context.DeadlineExceeded) {
+			return hostLoadedMsg{host: host, err: &remote.TimeoutError{Host: host, Op: "zmx list"}}
+		}
 		if err != nil {
 			return hostLoadedMsg{host: host, err: err}
 		}
 
-		// Build session items from zmx sessions
-		var items []Item
-		for _, zmxName := range zmxSessions {
-			// Parse session name from zmx name (format: session.tab.pane)
-			parts := strings.Split(zmxName, ".")
-			if len(parts) > 0 {
-				sessName := parts[0]
-				// Check if we already have this session
-				found := false
-				for i := range items {
-					if items[i].Name == sessName {
-						items[i].PaneCount++
-						found = true
-						break
-					}
-				}
-				if !found {
-					items = append(items, Item{
-						Type:      ItemSession,
-						Name:      sessName,
-						Host:      host,
-						PaneCount: 1,
-						Status:    "detached", // Remote sessions without kitty windows are detached
-					})
-				}
-			}
-		}
-
-		_ = ctx // context used for potential timeout
+		items := hostSessionItems(host, zmxSessions)
 		return hostLoadedMsg{host: host, sessions: items}
 	}
 }
 
+// hostSessionItems groups a host's zmx sessions into session items.
+// Names follow {session}.{tab}.{pane}, where the session name may itself
+// contain dots; zmx sessions outside that convention are shown as-is.
+func hostSessionItems(host string, zmxSessions []string) []Item {
+	var items []Item
+	index := make(map[string]int)
+	for _, zmxName := range model.DedupeZmxSessions(zmxSessions) {
+		sessName := model.ParseZmxSessionName(zmxName)
+		if sessName == "" {
+			sessName = zmxName
+		}
+		if i, ok := index[sessName]; ok {
+			items[i].PaneCount++
+			continue
+		}
+		index[sessName] = len(items)
+		items = append(items, Item{
+			Type:      ItemSession,
+			Name:      sessName,
+			Host:      host,
+			PaneCount: 1,
+			Status:    "detached", // Remote sessions without kitty windows are detached
+		})
+	}
+	return items
+}
+
 // Message types
 type dataLoadedMsg struct {
-	sessions []Item
-	projects []Item
-	host     string
+	sessions   []Item
+	projects   []Item
+	host       string
+	zmxMissing bool    // local zmx not installed
+	badDirs    []error // configured project directories that can't be scanned
 }
 
 type hostLoadingMsg struct {
@@ -298,6 +379,12 @@ func (m Model) LaunchLayout() string {
 	return m.launchLayout
 }
 
+// LaunchLayoutFile returns the selected layout with the pane commands edited
+// in the launch modal, or nil if no command was changed.
+func (m Model) LaunchLayoutFile() *config.Layout {
+	return m.launchEdited
+}
+
 // LaunchName returns the custom name for session creation, or empty for default.
 func (m Model) LaunchName() string {
 	return m.launchName
@@ -317,20 +404,56 @@ func (m Model) BrowserPath() string {
 }
 
 // rebuildItems creates the unified items list from sessions and projects.
+// Sessions are kept in a stable order (local first, then by host, status
+// and name) so refreshes don't move the cursor to a different session.
 func (m *Model) rebuildItems() {
+	sortSessionItems(m.sessions)
 	m.allItems = make([]Item, 0, len(m.sessions)+len(m.projects))
 	m.allItems = append(m.allItems, m.sessions...)
 	m.allItems = append(m.allItems, m.projects...)
 	m.applyFilter()
 }
 
+// sessionStatusRank orders session statuses from most to least live.
+var sessionStatusRank = map[string]int{"active": 0, "detached": 1, "saved": 2}
+
+// sortSessionItems sorts session items in place: local first, then by
+// host, status and name.
+func sortSessionItems(items []Item) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if (a.Host == "local") != (b.Host == "local") {
+			return a.Host == "local"
+		}
+		if a.Host != b.Host {
+			return a.Host < b.Host
+		}
+		if sessionStatusRank[a.Status] != sessionStatusRank[b.Status] {
+			return sessionStatusRank[a.Status] < sessionStatusRank[b.Status]
+		}
+		return a.Name < b.Name
+	})
+}
+
 // itemNames implements fuzzy.Source for fuzzy matching.
 type itemNames []Item
 
 func (s itemNames) String(i int) string { return s[i].Name }
 func (s itemNames) Len() int            { return len(s) }
 
+// itemPaths implements fuzzy.Source over project paths and session hosts.
+type itemPaths []Item
+
+func (s itemPaths) String(i int) string {
+	if s[i].Type == ItemSession {
+		return s[i].Host
+	}
+	return s[i].Path
+}
+func (s itemPaths) Len() int { return len(s) }
+
 // applyFilter filters items based on current filter input.
+// Name matches are ranked above path/host matches.
 func (m *Model) applyFilter() {
 	query := m.filterInput.Value()
 	if query == "" {
@@ -338,11 +461,22 @@ func (m *Model) applyFilter() {
 		return
 	}
 
-	// Fuzzy match existing items
-	matches := fuzzy.FindFrom(query, itemNames(m.allItems))
-	m.items = make([]Item, len(matches))
-	for i, match := range matches {
-		m.items[i] = m.allItems[match.Index]
+	// Fuzzy match names first, then fill in items that only match by path/host
+	nameMatches := fuzzy.FindFrom(query, itemNames(m.allItems))
+	pathMatches := fuzzy.FindFrom(query, itemPaths(m.allItems))
+
+	seen := make(map[int]bool, len(nameMatches))
+	m.items = make([]Item, 0, len(nameMatches)+len(pathMatches))
+	for _, match := range nameMatches {
+		seen[match.Index] = true
+		m.items = append(m.items, m.allItems[match.Index])
+	}
+	for _, match := range pathMatches {
+		if seen[match.Index] {
+			continue
+		}
+		seen[match.Index] = true
+		m.items = append(m.items, m.allItems[match.Index])
 	}
 }
 
@@ -350,6 +484,7 @@ func (m *Model) applyFilter() {
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		m.warning = ""
 		return m.handleKey(msg)
 
 	case tea.WindowSizeMsg:
@@ -360,6 +495,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case dataLoadedMsg:
 		m.sessions = msg.sessions
 		m.projects = msg.projects
+		var warnings []string
+		if msg.zmxMissing && !m.warned {
+			warnings = append(warnings, "zmx not found: persistence disabled, showing kitty sessions only")
+			m.warned = true
+		}
+		if len(msg.badDirs) > 0 && !m.dirsWarned {
+			warnings = append(warnings, projectDirsWarning(msg.badDirs))
+			m.dirsWarned = true
+		}
+		if len(warnings) > 0 {
+			m.warning = strings.Join(warnings, "; ")
+		}
 		m.rebuildItems()
 		// Start loading remote hosts after local data is ready
 		return m, m.startRemoteLoading()
@@ -551,13 +698,23 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Only allow rename on sessions
 		if m.SelectedSession() != "" {
 			m.renameMode = true
+			m.renameErr = ""
 			m.renameInput.SetValue("")
 			m.renameInput.Focus()
 			return m, textinput.Blink
 		}
 	case "R":
-		// Refresh - reload sessions and rescan projects
+		// Refresh - reload sessions and rescan projects, re-querying every host
+		for _, host := range m.state.ConfiguredHosts() {
+			m.state.InvalidateHostCache(host)
+		}
 		return m, m.loadDataAsync
+	case "e":
+		if len(m.hostErrors) > 0 {
+			m.showHostErrors = !m.showHostErrors
+		}
+		return m, nil
+
 	case "/":
 		m.filterMode = true
 		m.filterInput.Focus()
@@ -568,6 +725,7 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.launchMode = true
 			m.launchCursor = 0
 			m.launchNameFocus = false
+			m.closeLaunchPanes()
 			// Load available layouts
 			layouts, _ := store.ListLayouts()
 			m.launchLayouts = append([]string{"(none)"}, layouts...)
@@ -618,13 +776,22 @@ func (m Model) handleHostMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) handleLaunchMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.launchPaneFocus >= 0 {
+		return m.handleLaunchPanes(msg)
+	}
+
 	switch msg.String() {
 	case "esc":
 		m.launchMode = false
 		m.launchNameInput.Blur()
 		return m, nil
 	case "tab":
-		// Toggle focus between layout list and name field
+		// Cycle focus: layout list -> name field -> pane commands (if shown)
+		if m.launchNameFocus && len(m.launchPanes) > 0 {
+			m.launchNameFocus = false
+			m.launchNameInput.Blur()
+			return m, m.focusLaunchPane(0)
+		}
 		m.launchNameFocus = !m.launchNameFocus
 		if m.launchNameFocus {
 			m.launchNameInput.Focus()
@@ -635,10 +802,33 @@ func (m Model) handleLaunchMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "up", "k":
 		if !m.launchNameFocus && m.launchCursor > 0 {
 			m.launchCursor--
+			m.closeLaunchPanes()
 		}
 	case "down", "j":
 		if !m.launchNameFocus && m.launchCursor < len(m.launchLayouts)-1 {
 			m.launchCursor++
+			m.closeLaunchPanes()
+		}
+	case "e":
+		// Reveal the selected layout's pane commands for a one-off edit
+		if !m.launchNameFocus && m.launchCursor > 0 {
+			if m.launchTemplate == nil {
+				layout, err := store.LoadLayout(m.launchLayouts[m.launchCursor])
+				if err != nil {
+					m.warning = fmt.Sprintf("load layout: %v", err)
+					return m, nil
+				}
+				m.openLaunchPanes(layout)
+			}
+			if len(m.launchPanes) > 0 {
+				return m, m.focusLaunchPane(0)
+			}
+			return m, nil
+		}
+		if m.launchNameFocus {
+			var cmd tea.Cmd
+			m.launchNameInput, cmd = m.launchNameInput.Update(msg)
+			return m, cmd
 		}
 	case "enter":
 		// Confirm launch
@@ -654,6 +844,7 @@ func (m Model) handleLaunchMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		} else {
 			m.launchLayout = ""
 		}
+		m.launchEdited = m.editedLaunchLayout()
 
 		// Set name (use input value, or project name if empty)
 		name := m.launchNameInput.Value()
@@ -677,6 +868,113 @@ func (m Model) handleLaunchMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// handleLaunchPanes handles keys while a pane command input is focused.
+func (m Model) handleLaunchPanes(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		// Back to the layout list, keeping the edits
+		m.launchPanes[m.launchPaneFocus].Blur()
+		m.launchPaneFocus = -1
+		return m, nil
+	case "enter":
+		m.launchPanes[m.launchPaneFocus].Blur()
+		m.launchPaneFocus = -1
+		return m.handleLaunchMode(msg)
+	case "tab", "down":
+		if m.launchPaneFocus < len(m.launchPanes)-1 {
+			return m, m.focusLaunchPane(m.launchPaneFocus + 1)
+		}
+		if msg.String() == "tab" {
+			// Wrap around to the layout list
+			m.launchPanes[m.launchPaneFocus].Blur()
+			m.launchPaneFocus = -1
+		}
+		return m, nil
+	case "shift+tab", "up":
+		if m.launchPaneFocus > 0 {
+			return m, m.focusLaunchPane(m.launchPaneFocus - 1)
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.launchPanes[m.launchPaneFocus], cmd = m.launchPanes[m.launchPaneFocus].Update(msg)
+	return m, cmd
+}
+
+// openLaunchPanes creates a command input for every pane in layout.
+func (m *Model) openLaunchPanes(layout *config.Layout) {
+	m.launchTemplate = layout
+	m.launchPanes = nil
+	for _, tab := range layout.Tabs {
+		for _, command := range tab.Panes {
+			input := textinput.New()
+			input.Placeholder = "shell"
+			input.CharLimit = 256
+			input.Width = 30
+			input.SetValue(command)
+			m.launchPanes = append(m.launchPanes, input)
+		}
+	}
+	m.launchPaneFocus = -1
+}
+
+// closeLaunchPanes drops the pane command inputs, e.g. when another layout
+// is selected.
+func (m *Model) closeLaunchPanes() {
+	m.launchTemplate = nil
+	m.launchPanes = nil
+	m.launchPaneFocus = -1
+	m.launchEdited = nil
+}
+
+// focusLaunchPane moves input focus to pane command input i.
+func (m *Model) focusLaunchPane(i int) tea.Cmd {
+	if m.launchPaneFocus >= 0 {
+		m.launchPanes[m.launchPaneFocus].Blur()
+	}
+	m.launchPaneFocus = i
+	m.launchPanes[i].Focus()
+	return textinput.Blink
+}
+
+// editedLaunchLayout returns a copy of launchTemplate with the commands from
+// the pane inputs, or nil if none of them changed.
+func (m Model) editedLaunchLayout() *config.Layout {
+	if m.launchTemplate == nil {
+		return nil
+	}
+	edited := *m.launchTemplate
+	edited.Tabs = make([]config.LayoutTab, len(m.launchTemplate.Tabs))
+	changed := false
+	i := 0
+	for t, tab := range m.launchTemplate.Tabs {
+		tab.Panes = append([]string(nil), tab.Panes...)
+		for p := range tab.Panes {
+			command := strings.TrimSpace(m.launchPanes[i].Value())
+			if command != tab.Panes[p] {
+				tab.Panes[p] = command
+				changed = true
+			}
+			i++
+		}
+		edited.Tabs[t] = tab
+	}
+	if !changed {
+		return nil
+	}
+	return &edited
+}
+
+// browserCommand returns the configured file browser command writing to chooserFile.
+func (m Model) browserCommand(chooserFile string) string {
+	cfg := m.cfg
+	if cfg == nil {
+		cfg = config.DefaultConfig()
+	}
+	return cfg.BrowserCommand(chooserFile)
+}
+
 // yaziFinishedMsg is sent when yazi exits
 type yaziFinishedMsg struct {
 	path string
@@ -704,12 +1002,12 @@ func (m Model) openYazi() tea.Cmd {
 	tmpFile := "/tmp/kmux-yazi-choice"
 	os.Remove(tmpFile)
 
-	// Build yazi command - run through user's login shell to get proper PATH
+	// Build browser command - run through user's login shell to get proper PATH
 	shell := os.Getenv("SHELL")
 	if shell == "" {
 		shell = "sh"
 	}
-	shellCmd := "yazi --chooser-file=" + tmpFile + " " + startPath
+	shellCmd := m.browserCommand(tmpFile) + " " + startPath
 	cmd := exec.Command(shell, "-l", "-c", shellCmd)
 
 	// Use tea.ExecProcess to let yazi take over the terminal
@@ -730,12 +1028,22 @@ func (m Model) openYazi() tea.Cmd {
 	})
 }
 
+// sshDestination returns the ssh arguments naming host ([hosts] ssh_target
+// and port, or the alias itself).
+func (m Model) sshDestination(host string) []string {
+	if m.cfg == nil {
+		return []string{host}
+	}
+	return m.cfg.GetHost(host).SSHDestination(host)
+}
+
 // openYaziRemote spawns yazi over SSH to browse a remote host
 func (m Model) openYaziRemote(host string) tea.Cmd {
 	// Run yazi on remote with chooser-file; after exit, read the chosen path back
 	remoteChooserFile := "/tmp/kmux-yazi-choice"
-	remoteCmd := fmt.Sprintf("rm -f %s && yazi --chooser-file=%s", remoteChooserFile, remoteChooserFile)
-	cmd := exec.Command("kitten", "ssh", host, "-t", remoteCmd)
+	remoteCmd := fmt.Sprintf("rm -f %s && %s", remoteChooserFile, m.browserCommand(remoteChooserFile))
+	args := append([]string{"ssh"}, m.sshDestination(host)...)
+	cmd := exec.Command("kitten", append(args, "-t", remoteCmd)...)
 
 	return tea.ExecProcess(cmd, func(err error) tea.Msg {
 		if err != nil {
@@ -743,7 +1051,7 @@ func (m Model) openYaziRemote(host string) tea.Cmd {
 		}
 
 		// Read the chosen path from the remote
-		readCmd := exec.Command("ssh", host, "cat "+remoteChooserFile+" 2>/dev/null")
+		readCmd := exec.Command("ssh", append(m.sshDestination(host), "cat "+remoteChooserFile+" 2>/dev/null")...)
 		out, readErr := readCmd.Output()
 		path := strings.TrimSpace(string(out))
 		if readErr != nil || path == "" {
@@ -795,6 +1103,7 @@ func (m Model) handleConfirmKill(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			if host == "local" {
 				m.state.Store().DeleteSession(session)
 			}
+			m.state.InvalidateHostCache(host)
 			return nil // Silently sync - UI already updated
 		}
 	case "n", "N", "esc":
@@ -866,8 +1175,17 @@ func (m Model) handleRenameMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "enter":
 		newName := m.renameInput.Value()
-		if newName != "" && m.SelectedSession() != "" {
-			if err := m.state.Store().RenameSession(m.SelectedSession(), newName); err == nil {
+		if newName == "" {
+			return m, nil
+		}
+		if m.renameErr = m.validateRename(newName); m.renameErr != "" {
+			return m, nil // keep editing until the name is valid
+		}
+		if m.SelectedSession() != "" {
+			report, err := manager.RenameSession(m.state, m.SelectedSession(), newName, m.SelectedSessionHost(), manager.RenameOpts{})
+			if err != nil {
+				m.warning = fmt.Sprintf("rename failed: %v", err)
+			} else {
 				// Update the session name in both lists
 				for i := range m.sessions {
 					if m.sessions[i].Name == m.SelectedSession() {
@@ -876,6 +1194,13 @@ func (m Model) handleRenameMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 					}
 				}
 				m.rebuildItems()
+				if len(report.Failures) > 0 {
+					failed := make([]string, len(report.Failures))
+					for i, f := range report.Failures {
+						failed[i] = f.Error()
+					}
+					m.warning = "rename incomplete: " + strings.Join(failed, "; ")
+				}
 			}
 		}
 		m.renameMode = false
@@ -887,11 +1212,30 @@ func (m Model) handleRenameMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	default:
 		var cmd tea.Cmd
 		m.renameInput, cmd = m.renameInput.Update(msg)
+		m.renameErr = ""
+		if value := m.renameInput.Value(); value != "" {
+			m.renameErr = m.validateRename(value)
+		}
 		return m, cmd
 	}
 	return m, nil
 }
 
+// validateRename checks a new name for the selected session, returning a
+// message to show under the rename input or "" if the name is usable.
+func (m Model) validateRename(newName string) string {
+	if err := store.ValidateSessionName(newName); err != nil {
+		return err.Error()
+	}
+	host := m.SelectedSessionHost()
+	for _, sess := range m.sessions {
+		if sess.Name == newName && sess.Host == host {
+			return fmt.Sprintf("session %q already exists", newName)
+		}
+	}
+	return ""
+}
+
 // LoadingHosts returns a list of hosts currently being loaded.
 func (m Model) LoadingHosts() []string {
 	var hosts []string
@@ -1,9 +1,13 @@
 package tui
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cwel/kmux/internal/config"
+	"github.com/cwel/kmux/internal/state"
 )
 
 func TestModel_Navigation(t *testing.T) {
@@ -148,6 +152,52 @@ func TestModel_CreateActionForProject(t *testing.T) {
 	}
 }
 
+func TestModel_LaunchEditPaneCommands(t *testing.T) {
+	m := New(nil, nil)
+	m.projects = []Item{{Type: ItemProject, Name: "web", Path: "/src/web"}}
+	m.rebuildItems()
+
+	// As if "l" was pressed and the "dev" layout revealed with "e"
+	m.launchMode = true
+	m.launchLayouts = []string{"(none)", "dev"}
+	m.launchCursor = 1
+	m.openLaunchPanes(&config.Layout{
+		Name: "dev",
+		Tabs: []config.LayoutTab{{Title: "main", Layout: "tall", Panes: []string{"nvim", "npm run dev"}}},
+	})
+	m.focusLaunchPane(0)
+
+	key := func(msg tea.KeyMsg) {
+		updated, _ := m.Update(msg)
+		m = updated.(Model)
+	}
+	key(tea.KeyMsg{Type: tea.KeyDown})
+	m.launchPanes[1].SetValue("npm run test")
+	key(tea.KeyMsg{Type: tea.KeyEnter})
+
+	if m.action != "create" || m.LaunchLayout() != "dev" {
+		t.Fatalf("action = %q, layout = %q, want create with dev", m.action, m.LaunchLayout())
+	}
+	edited := m.LaunchLayoutFile()
+	if edited == nil {
+		t.Fatal("LaunchLayoutFile() = nil, want the edited layout")
+	}
+	if got := edited.Tabs[0].Panes; got[0] != "nvim" || got[1] != "npm run test" {
+		t.Errorf("edited panes = %q", got)
+	}
+	if m.launchTemplate.Tabs[0].Panes[1] != "npm run dev" {
+		t.Error("editing changed the loaded template")
+	}
+}
+
+func TestModel_LaunchUneditedPanes(t *testing.T) {
+	m := New(nil, nil)
+	m.openLaunchPanes(&config.Layout{Tabs: []config.LayoutTab{{Panes: []string{"nvim"}}}})
+	if m.editedLaunchLayout() != nil {
+		t.Error("editedLaunchLayout() should be nil when no command changed")
+	}
+}
+
 func TestModel_NavigationAcrossSections(t *testing.T) {
 	m := New(nil, nil)
 	m.sessions = []Item{
@@ -189,3 +239,287 @@ func TestModel_DeleteOnlyWorksOnSessions(t *testing.T) {
 		t.Error("expected confirmKill false when project selected")
 	}
 }
+
+func TestModel_FilterByPath(t *testing.T) {
+	m := New(nil, nil)
+	m.projects = []Item{
+		{Type: ItemProject, Name: "api", Path: "/src/acme/api"},
+		{Type: ItemProject, Name: "api", Path: "/src/globex/api"},
+		{Type: ItemProject, Name: "web", Path: "/src/globex/web"},
+	}
+	m.rebuildItems()
+
+	m.filterInput.SetValue("globex")
+	m.applyFilter()
+
+	if len(m.items) != 2 {
+		t.Fatalf("expected 2 path matches, got %d", len(m.items))
+	}
+	for _, item := range m.items {
+		if item.Path == "/src/acme/api" {
+			t.Errorf("unexpected match %q for path query", item.Path)
+		}
+	}
+}
+
+func TestModel_FilterNameRankedAbovePath(t *testing.T) {
+	m := New(nil, nil)
+	m.projects = []Item{
+		{Type: ItemProject, Name: "tools", Path: "/src/web/tools"},
+		{Type: ItemProject, Name: "web", Path: "/src/web"},
+	}
+	m.rebuildItems()
+
+	m.filterInput.SetValue("web")
+	m.applyFilter()
+
+	if len(m.items) != 2 {
+		t.Fatalf("expected 2 matches, got %d", len(m.items))
+	}
+	if m.items[0].Name != "web" {
+		t.Errorf("expected name match first, got %q", m.items[0].Name)
+	}
+}
+
+func TestModel_FilterBySessionHost(t *testing.T) {
+	m := New(nil, nil)
+	m.sessions = []Item{
+		{Type: ItemSession, Name: "work", Host: "local"},
+		{Type: ItemSession, Name: "work", Host: "devbox"},
+	}
+	m.rebuildItems()
+
+	m.filterInput.SetValue("devbox")
+	m.applyFilter()
+
+	if len(m.items) != 1 || m.items[0].Host != "devbox" {
+		t.Errorf("expected only devbox session, got %+v", m.items)
+	}
+}
+
+func TestModel_ZmxMissingWarning(t *testing.T) {
+	m := New(&state.State{}, nil)
+
+	updated, _ := m.Update(dataLoadedMsg{host: "local", zmxMissing: true})
+	m = updated.(Model)
+	if m.warning == "" {
+		t.Fatal("expected zmx warning after load")
+	}
+
+	// Cleared on next key and not shown again on reload
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	m = updated.(Model)
+	updated, _ = m.Update(dataLoadedMsg{host: "local", zmxMissing: true})
+	m = updated.(Model)
+	if m.warning != "" {
+		t.Errorf("warning = %q, want empty after dismissal", m.warning)
+	}
+}
+
+func TestModel_ProjectDirsWarning(t *testing.T) {
+	m := New(&state.State{}, nil)
+
+	bad := []error{errors.New("/src/typo: no such directory")}
+	updated, _ := m.Update(dataLoadedMsg{host: "local", badDirs: bad})
+	m = updated.(Model)
+	if !strings.Contains(m.warning, "/src/typo") {
+		t.Errorf("warning = %q, want it to name the missing directory", m.warning)
+	}
+
+	bad = append(bad, errors.New("/src/other: permission denied"))
+	if got := projectDirsWarning(bad); !strings.HasPrefix(got, "2 project directories") {
+		t.Errorf("projectDirsWarning() = %q", got)
+	}
+}
+
+func TestModel_ZmxMissingAndProjectDirsWarning(t *testing.T) {
+	m := New(&state.State{}, nil)
+
+	bad := []error{errors.New("/src/typo: no such directory")}
+	updated, _ := m.Update(dataLoadedMsg{host: "local", zmxMissing: true, badDirs: bad})
+	m = updated.(Model)
+	if !strings.Contains(m.warning, "zmx not found") || !strings.Contains(m.warning, "/src/typo") {
+		t.Errorf("warning = %q, want both the zmx and the project directory warning", m.warning)
+	}
+
+	// A directory going missing later is still reported once zmx was warned about
+	m = New(&state.State{}, nil)
+	updated, _ = m.Update(dataLoadedMsg{host: "local", zmxMissing: true})
+	m = updated.(Model)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	m = updated.(Model)
+	updated, _ = m.Update(dataLoadedMsg{host: "local", zmxMissing: true, badDirs: bad})
+	m = updated.(Model)
+	if m.warning != projectDirsWarning(bad) {
+		t.Errorf("warning = %q, want only the project directory warning", m.warning)
+	}
+}
+
+func TestModel_HostStatus(t *testing.T) {
+	m := New(nil, nil)
+	m.sessions = []Item{
+		{Type: ItemSession, Name: "a", Host: "local"},
+		{Type: ItemSession, Name: "b", Host: "local"},
+		{Type: ItemSession, Name: "c", Host: "work"},
+	}
+	if got := m.statusHosts(); len(got) != 2 || got[0] != "local" || got[1] != "work" {
+		t.Fatalf("statusHosts() = %v, want [local work]", got)
+	}
+
+	m.loadingHosts["home"] = true
+	m.hostErrors["vps"] = errors.New("ssh: connection refused")
+	status := m.viewHostStatus()
+	for _, want := range []string{"local: 2", "work: 1", "home: (loading)", "vps: (error)"} {
+		if !strings.Contains(status, want) {
+			t.Errorf("status %q missing %q", status, want)
+		}
+	}
+	if strings.Contains(status, "connection refused") {
+		t.Error("error message should be hidden until expanded")
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+	m = updated.(Model)
+	if !strings.Contains(m.viewHostStatus(), "connection refused") {
+		t.Error("expected error message after pressing e")
+	}
+}
+
+func TestModel_HostStatusLocalOnly(t *testing.T) {
+	m := New(nil, nil)
+	m.sessions = []Item{{Type: ItemSession, Name: "a", Host: "local"}}
+	if got := m.viewHostStatus(); got != "" {
+		t.Errorf("viewHostStatus() = %q, want empty for local-only", got)
+	}
+}
+
+func TestModel_StatusIndicators(t *testing.T) {
+	m := New(nil, nil)
+
+	glyphs := make(map[string]bool)
+	for _, status := range []string{"active", "detached", "saved"} {
+		line := m.renderItem(Item{Type: ItemSession, Name: "s", Status: status}, 40)
+		for _, g := range []string{"●", "◐", "○"} {
+			if strings.Contains(line, g) {
+				glyphs[g] = true
+			}
+		}
+	}
+	if len(glyphs) != 3 {
+		t.Errorf("expected distinct glyphs per status, got %v", glyphs)
+	}
+}
+
+func TestHostSessionItems_DottedNames(t *testing.T) {
+	items := hostSessionItems("devbox", []string{"my.app.0.0", "my.app.0.1", "my.app.1.0", "work.0.0", "standalone"})
+
+	want := map[string]int{"my.app": 3, "work": 1, "standalone": 1}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d: %+v", len(items), len(want), items)
+	}
+	for _, item := range items {
+		if item.PaneCount != want[item.Name] {
+			t.Errorf("%s: PaneCount = %d, want %d", item.Name, item.PaneCount, want[item.Name])
+		}
+		if item.Host != "devbox" {
+			t.Errorf("%s: Host = %q, want devbox", item.Name, item.Host)
+		}
+	}
+}
+
+func TestHostSessionItems_DuplicatePanes(t *testing.T) {
+	items := hostSessionItems("devbox", []string{"proj.0.0", "proj.0.1", "proj.0.0"})
+	if len(items) != 1 || items[0].PaneCount != 2 {
+		t.Fatalf("items = %+v, want one session with 2 panes", items)
+	}
+}
+
+func TestSortSessionItems(t *testing.T) {
+	items := []Item{
+		{Name: "zeta", Host: "devbox", Status: "detached"},
+		{Name: "beta", Host: "local", Status: "saved"},
+		{Name: "alpha", Host: "local", Status: "detached"},
+		{Name: "gamma", Host: "local", Status: "active"},
+		{Name: "alpha", Host: "devbox", Status: "detached"},
+	}
+	sortSessionItems(items)
+
+	want := []string{"gamma@local", "alpha@local", "beta@local", "alpha@devbox", "zeta@devbox"}
+	for i, item := range items {
+		if got := item.Name + "@" + item.Host; got != want[i] {
+			t.Errorf("item %d = %s, want %s", i, got, want[i])
+		}
+	}
+}
+
+func TestModel_RenameValidation(t *testing.T) {
+	m := New(nil, nil)
+	m.sessions = []Item{
+		{Type: ItemSession, Name: "api", Host: "local"},
+		{Type: ItemSession, Name: "web", Host: "local"},
+	}
+	m.rebuildItems()
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	m = updated.(Model)
+	if !m.renameMode {
+		t.Fatal("expected rename mode")
+	}
+
+	typeText := func(s string) {
+		updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)})
+		m = updated.(Model)
+	}
+
+	typeText("a/b")
+	if m.renameErr == "" {
+		t.Error("expected an error for a name containing '/'")
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if !m.renameMode {
+		t.Error("enter with an invalid name should keep rename mode open")
+	}
+
+	m.renameInput.SetValue("")
+	typeText("web")
+	if !strings.Contains(m.renameErr, "already exists") {
+		t.Errorf("renameErr = %q, want an 'already exists' error", m.renameErr)
+	}
+	m.width, m.height = 80, 24
+	if !strings.Contains(m.View(), "already exists") {
+		t.Error("view should show the rename error")
+	}
+
+	m.renameInput.SetValue("")
+	typeText("docs")
+	if m.renameErr != "" {
+		t.Errorf("renameErr = %q, want none for a free name", m.renameErr)
+	}
+}
+
+func TestModel_WithHost(t *testing.T) {
+	m := New(nil, nil).WithHost("work")
+	if m.selectedHost != "work" {
+		t.Errorf("selectedHost = %q, want work", m.selectedHost)
+	}
+	if m.loadsHost("local") || m.loadsHost("home") || !m.loadsHost("work") {
+		t.Error("a work-scoped model should only load work")
+	}
+
+	// Scoped to a remote host, local sessions and projects are skipped
+	msg, ok := m.loadDataAsync().(dataLoadedMsg)
+	if !ok {
+		t.Fatalf("loadDataAsync() returned %T, want dataLoadedMsg", msg)
+	}
+	if len(msg.sessions) != 0 || len(msg.projects) != 0 {
+		t.Errorf("got %d sessions, %d projects; want none", len(msg.sessions), len(msg.projects))
+	}
+
+	if hosts := m.statusHosts(); len(hosts) != 1 || hosts[0] != "work" {
+		t.Errorf("statusHosts() = %v, want [work]", hosts)
+	}
+	if unscoped := New(nil, nil); !unscoped.loadsHost("local") || !unscoped.loadsHost("work") {
+		t.Error("an unscoped model should load every host")
+	}
+}
@@ -1,6 +1,9 @@
 package tui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/cwel/kmux/internal/config"
+)
 
 // Catppuccin Mocha palette
 var (
@@ -9,6 +12,7 @@ var (
 	lavender = lipgloss.Color("#b4befe") // accent
 	green    = lipgloss.Color("#a6e3a1") // success
 	peach    = lipgloss.Color("#fab387") // warning
+	red      = lipgloss.Color("#f38ba8") // error
 
 	// Neutral tones
 	subtext1 = lipgloss.Color("#bac2de")
@@ -21,7 +25,6 @@ var (
 var (
 	// Theme aliases
 	primaryColor = blue
-	successColor = green
 
 	// Borders
 	borderStyle = lipgloss.NewStyle().
@@ -44,15 +47,7 @@ var (
 				Foreground(primaryColor).
 				Bold(true)
 
-	// Status indicators
-	runningIndicator = lipgloss.NewStyle().
-				Foreground(successColor).
-				SetString("●")
-
-	savedIndicator = lipgloss.NewStyle().
-			Foreground(overlay0).
-			SetString("○")
-
+	// Status indicators (session indicators come from config, see statusIndicators)
 	projectIndicator = lipgloss.NewStyle().
 				Foreground(peach).
 				SetString("◆")
@@ -67,6 +62,14 @@ var (
 			Foreground(overlay1).
 			Padding(1, 2)
 
+	// Host status line
+	statusStyle = lipgloss.NewStyle().
+			Foreground(overlay1).
+			PaddingLeft(2)
+
+	hostErrorStyle = lipgloss.NewStyle().
+			Foreground(red)
+
 	// Preview pane
 	previewTitleStyle = lipgloss.NewStyle().
 				Bold(true).
@@ -79,3 +82,20 @@ var (
 	dimStyle = lipgloss.NewStyle().
 			Foreground(overlay0)
 )
+
+// statusIndicators builds the session status glyphs ("active", "detached",
+// "saved") from the [tui] config section.
+func statusIndicators(cfg *config.Config) map[string]lipgloss.Style {
+	tc := config.DefaultConfig().TUI
+	if cfg != nil {
+		tc = cfg.TUI
+	}
+	indicator := func(glyph, color string) lipgloss.Style {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(color)).SetString(glyph)
+	}
+	return map[string]lipgloss.Style{
+		"active":   indicator(tc.ActiveGlyph, tc.ActiveColor),
+		"detached": indicator(tc.DetachedGlyph, tc.DetachedColor),
+		"saved":    indicator(tc.SavedGlyph, tc.SavedColor),
+	}
+}
@@ -3,6 +3,7 @@ package tui
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
@@ -32,18 +33,26 @@ func (m Model) View() string {
 		if m.cursor < len(m.sessions) {
 			selectedName = m.sessions[m.cursor].Name
 		}
-		return fmt.Sprintf(
+		view := fmt.Sprintf(
 			"%s\n\n%s\n\n%s",
 			titleStyle.Render("Rename Session"),
 			fmt.Sprintf("Renaming: %s", selectedName),
 			m.renameInput.View(),
 		)
+		if m.renameErr != "" {
+			view += "\n" + hostErrorStyle.Render(m.renameErr)
+		}
+		return view
 	}
 
 	// Calculate pane widths
 	listWidth := m.width/2 - 2
 	previewWidth := m.width - listWidth - 4
 	contentHeight := m.height - 6 // account for borders, title, and help bar padding
+	hostStatus := m.viewHostStatus()
+	if hostStatus != "" {
+		contentHeight -= lipgloss.Height(hostStatus)
+	}
 
 	// Build panes
 	listPane := m.viewSessionList(listWidth, contentHeight)
@@ -67,9 +76,88 @@ func (m Model) View() string {
 		content = m.viewHostModal(m.width, m.height)
 	}
 
+	if hostStatus != "" {
+		return lipgloss.JoinVertical(lipgloss.Left, title, content, hostStatus, helpBar)
+	}
 	return lipgloss.JoinVertical(lipgloss.Left, title, content, helpBar)
 }
 
+// statusHosts returns the hosts shown in the status line: local first,
+// then configured and otherwise known remote hosts in sorted order.
+func (m Model) statusHosts() []string {
+	if m.hostFilter != "" {
+		return []string{m.hostFilter}
+	}
+	seen := map[string]bool{"local": true}
+	var remotes []string
+	add := func(host string) {
+		if host != "" && !seen[host] {
+			seen[host] = true
+			remotes = append(remotes, host)
+		}
+	}
+	if m.state != nil {
+		for _, host := range m.state.ConfiguredHosts() {
+			add(host)
+		}
+	}
+	for _, s := range m.sessions {
+		add(s.Host)
+	}
+	for host := range m.loadingHosts {
+		add(host)
+	}
+	for host := range m.hostErrors {
+		add(host)
+	}
+	sort.Strings(remotes)
+	return append([]string{"local"}, remotes...)
+}
+
+// viewHostStatus renders per-host session counts, e.g.
+// "local: 4 · work: 2 · home: (loading) · vps: (error)".
+// Returns empty string when only the local host is involved.
+func (m Model) viewHostStatus() string {
+	hosts := m.statusHosts()
+	if len(hosts) < 2 {
+		return ""
+	}
+
+	counts := make(map[string]int)
+	for _, s := range m.sessions {
+		host := s.Host
+		if host == "" {
+			host = "local"
+		}
+		counts[host]++
+	}
+
+	parts := make([]string, len(hosts))
+	for i, host := range hosts {
+		switch {
+		case m.hostErrors[host] != nil:
+			parts[i] = hostErrorStyle.Render(host + ": (error)")
+		case m.loadingHosts[host]:
+			parts[i] = host + ": (loading)"
+		default:
+			parts[i] = fmt.Sprintf("%s: %d", host, counts[host])
+		}
+	}
+	line := strings.Join(parts, " · ")
+	if len(m.hostErrors) > 0 && !m.showHostErrors {
+		line += "  [e] details"
+	}
+
+	if m.showHostErrors {
+		for _, host := range hosts {
+			if err := m.hostErrors[host]; err != nil {
+				line += "\n" + hostErrorStyle.Render(fmt.Sprintf("%s: %v", host, err))
+			}
+		}
+	}
+	return statusStyle.Render(line)
+}
+
 func (m Model) viewSessionList(width, height int) string {
 	var b strings.Builder
 
@@ -94,7 +182,11 @@ func (m Model) viewSessionList(width, height int) string {
 		itemIdx := 0
 
 		// Sessions section
-		b.WriteString(sectionHeaderStyle.Render("Sessions") + "\n")
+		header := "Sessions"
+		if m.hostFilter != "" {
+			header = m.hostFilter + ": " + header
+		}
+		b.WriteString(sectionHeaderStyle.Render(header) + "\n")
 
 		if len(m.sessions) == 0 && len(m.loadingHosts) == 0 {
 			b.WriteString(dimStyle.Render("  No sessions") + "\n")
@@ -145,10 +237,11 @@ func (m Model) viewSessionList(width, height int) string {
 
 func (m Model) renderItem(item Item, width int) string {
 	if item.Type == ItemSession {
-		indicator := savedIndicator.String()
-		if item.Status == "active" || item.Status == "detached" {
-			indicator = runningIndicator.String()
+		style, ok := m.indicators[item.Status]
+		if !ok {
+			style = m.indicators["saved"]
 		}
+		indicator := style.String()
 
 		// Format name with host suffix for non-local sessions
 		name := item.Name
@@ -156,6 +249,10 @@ func (m Model) renderItem(item Item, width int) string {
 			name = fmt.Sprintf("%s@%s", item.Name, item.Host)
 		}
 
+		if item.ReadOnly {
+			name += " 🔒"
+		}
+
 		displayName := fmt.Sprintf("%s %s", indicator, name)
 		panes := fmt.Sprintf("(%d)", item.PaneCount)
 		return fmt.Sprintf("%-*s %s", width-8, displayName, panes)
@@ -214,6 +311,9 @@ func (m Model) viewPreview(width, height int) string {
 }
 
 func (m Model) viewHelpBar() string {
+	if m.warning != "" {
+		return helpStyle.Render("⚠ " + m.warning)
+	}
 	if m.filterMode {
 		return helpStyle.Render("/ " + m.filterInput.View() + "  [enter] keep  [esc] clear")
 	}
@@ -245,6 +345,7 @@ func (m Model) viewHelp() string {
     d         Delete session / hide project
     r         Rename session
     R         Refresh list
+    e         Show host errors
     /         Filter (fuzzy search)
     ?         Toggle help
     q/esc     Quit (esc clears filter first)
@@ -336,9 +437,25 @@ func (m Model) viewLaunchModal(width, height int) string {
 	b.WriteString(nameLabel + "\n")
 	b.WriteString("  " + m.launchNameInput.View() + "\n")
 
+	// Pane commands of the selected layout, once revealed with [e]
+	if len(m.launchPanes) > 0 {
+		b.WriteString("\n")
+		panesLabel := previewInfoStyle.Render("Pane commands:")
+		if m.launchPaneFocus >= 0 {
+			panesLabel = selectedItemStyle.Render("Pane commands:")
+		}
+		b.WriteString(panesLabel + "\n")
+		for i, input := range m.launchPanes {
+			b.WriteString(fmt.Sprintf("  %d %s\n", i+1, input.View()))
+		}
+	}
+
 	// Help
 	b.WriteString("\n")
 	b.WriteString(dimStyle.Render("[↑/↓] select  [tab] switch  [enter] launch  [esc] cancel"))
+	if m.launchCursor > 0 && len(m.launchPanes) == 0 {
+		b.WriteString("\n" + dimStyle.Render("[e] edit pane commands"))
+	}
 
 	style := borderStyle.Width(45).Padding(1, 2)
 	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, style.Render(b.String()))
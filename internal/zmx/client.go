@@ -2,18 +2,43 @@ package zmx
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
 
 	"github.com/cwel/kmux/internal/config"
 )
 
+// ErrZmxUnavailable is returned when the zmx binary can't be found.
+var ErrZmxUnavailable = errors.New("zmx not installed")
+
+// ErrNoSessions is returned by List when zmx has no running sessions.
+var ErrNoSessions = errors.New("no zmx sessions")
+
 // Client communicates with zmx CLI, either locally or over SSH.
 type Client struct {
 	host    string             // SSH alias or "local"
 	hostCfg *config.HostConfig // nil for local
+
+	capsOnce sync.Once    // probes zmx once per client
+	caps     Capabilities // cached result of the probe
+}
+
+// Capabilities describes what the installed zmx supports, so optional
+// features can be gated up front instead of failing at call time.
+type Capabilities struct {
+	Version     string          // first line of zmx --version ("" if unknown)
+	Subcommands map[string]bool // subcommands listed by zmx help
+	ReadOnly    bool            // attach accepts --read-only
+}
+
+// Has reports whether zmx lists the given subcommand.
+func (c Capabilities) Has(subcmd string) bool {
+	return c.Subcommands[subcmd]
 }
 
 // NewClient creates a local zmx client.
@@ -49,10 +74,16 @@ func (c *Client) zmxPath() string {
 
 // runZmx runs a zmx command, either locally or over SSH.
 func (c *Client) runZmx(args ...string) *exec.Cmd {
+	return c.runZmxContext(context.Background(), args...)
+}
+
+// runZmxContext is runZmx with a context; the process (local shell or ssh)
+// is killed when ctx is done.
+func (c *Client) runZmxContext(ctx context.Context, args ...string) *exec.Cmd {
 	if c.IsRemote() {
 		// Build SSH command: ssh <alias> "zmx <args>"
 		zmxCmd := c.zmxPath() + " " + strings.Join(args, " ")
-		return exec.Command("ssh", c.host, zmxCmd)
+		return c.sshCmd(ctx, zmxCmd)
 	}
 
 	// Local: run through login shell to ensure proper PATH
@@ -61,7 +92,27 @@ func (c *Client) runZmx(args ...string) *exec.Cmd {
 		shell = "/bin/sh"
 	}
 	shellCmd := "zmx " + strings.Join(args, " ")
-	return exec.Command(shell, "-lc", shellCmd)
+	return exec.CommandContext(ctx, shell, "-lc", shellCmd)
+}
+
+// sshCmd builds an ssh command running remoteCmd on the client's host,
+// reusing a multiplexed connection when available.
+func (c *Client) sshCmd(ctx context.Context, remoteCmd string) *exec.Cmd {
+	c.hostCfg.EnsureControlMaster(ctx, c.host)
+	args := append(c.hostCfg.SSHArgs(), c.hostCfg.SSHDestination(c.host)...)
+	args = append(args, remoteCmd)
+	return exec.CommandContext(ctx, "ssh", args...)
+}
+
+// isNotFound reports whether err means the zmx binary could not be run.
+// Local commands go through a login shell and remote ones through ssh,
+// both of which exit 127 when the command doesn't exist.
+func isNotFound(err error) bool {
+	if errors.Is(err, exec.ErrNotFound) {
+		return true
+	}
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr) && exitErr.ExitCode() == 127
 }
 
 // ParseList parses output from `zmx list`.
@@ -69,7 +120,7 @@ func (c *Client) runZmx(args ...string) *exec.Cmd {
 // Sessions with status=Timeout (cleaning up) are filtered out.
 func ParseList(output string) []string {
 	output = strings.TrimSpace(output)
-	if output == "" || strings.Contains(output, "no sessions found") {
+	if output == "" || isNoSessions(output) {
 		return nil
 	}
 
@@ -98,23 +149,44 @@ func ParseList(output string) []string {
 	return sessions
 }
 
-// List returns all active zmx sessions.
+// isNoSessions reports whether zmx output says there are no sessions.
+func isNoSessions(output string) bool {
+	return strings.Contains(output, "no sessions found")
+}
+
+// List returns all active zmx sessions, or ErrNoSessions if there are none.
 func (c *Client) List() ([]string, error) {
-	cmd := c.runZmx("list")
+	return c.ListContext(context.Background())
+}
+
+// ListContext is List bounded by ctx. If ctx ends before zmx answers (e.g. a
+// hung SSH connection), the command is killed and ctx.Err() is returned.
+func (c *Client) ListContext(ctx context.Context) ([]string, error) {
+	cmd := c.runZmxContext(ctx, "list")
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if isNotFound(err) {
+			return nil, ErrZmxUnavailable
+		}
 		// zmx list returns error if no sessions, check stderr
 		errStr := stderr.String()
-		if strings.Contains(errStr, "no sessions found") {
-			return nil, nil
+		if isNoSessions(errStr) {
+			return nil, fmt.Errorf("zmx list: %w: %s", ErrNoSessions, strings.TrimSpace(errStr))
 		}
 		return nil, fmt.Errorf("zmx list: %w: %s", err, errStr)
 	}
 
-	return ParseList(stdout.String()), nil
+	sessions := ParseList(stdout.String())
+	if len(sessions) == 0 {
+		return nil, ErrNoSessions
+	}
+	return sessions, nil
 }
 
 // Kill terminates a zmx session.
@@ -132,6 +204,118 @@ func (c *Client) Kill(name string) error {
 	return nil
 }
 
+// Capabilities probes zmx (--version and help) on first use and returns the
+// cached result; a new client probes again.
+func (c *Client) Capabilities() Capabilities {
+	c.capsOnce.Do(func() {
+		c.caps = parseCapabilities(c.probe("--version"), c.probe("help"))
+	})
+	return c.caps
+}
+
+// probe runs a zmx informational command and returns its combined output.
+func (c *Client) probe(args ...string) string {
+	cmd := c.runZmx(args...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	cmd.Run() // help may exit non-zero; the output is what matters
+	return out.String()
+}
+
+// parseCapabilities extracts capabilities from zmx --version and help output.
+// Subcommands are lines whose first word is a bare lowercase command name.
+func parseCapabilities(version, help string) Capabilities {
+	caps := Capabilities{
+		Subcommands: make(map[string]bool),
+		ReadOnly:    strings.Contains(help, "--read-only"),
+	}
+	if line, _, _ := strings.Cut(strings.TrimSpace(version), "\n"); !isNotFoundOutput(line) {
+		caps.Version = strings.TrimSpace(line)
+	}
+	for _, line := range strings.Split(help, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && fields[0] != "zmx" && isSubcommandName(fields[0]) {
+			caps.Subcommands[fields[0]] = true
+		}
+	}
+	return caps
+}
+
+// isSubcommandName reports whether word looks like a subcommand (e.g. "attach").
+func isSubcommandName(word string) bool {
+	for _, r := range word {
+		if (r < 'a' || r > 'z') && r != '-' {
+			return false
+		}
+	}
+	return word != "" && word[0] != '-'
+}
+
+// isNotFoundOutput reports whether a probe's output is a shell's
+// "command not found" message rather than zmx output.
+func isNotFoundOutput(line string) bool {
+	return strings.Contains(line, "not found") || strings.Contains(line, "No such file")
+}
+
+// SupportsRename reports whether the installed zmx has a rename subcommand.
+func (c *Client) SupportsRename() bool {
+	return c.Capabilities().Has("rename")
+}
+
+// SupportsReadOnly reports whether the installed zmx can attach read-only.
+func (c *Client) SupportsReadOnly() bool {
+	return c.Capabilities().ReadOnly
+}
+
+// Rename renames a zmx session. Callers should check SupportsRename first.
+func (c *Client) Rename(oldName, newName string) error {
+	if oldName == "" || newName == "" {
+		return fmt.Errorf("zmx rename: old and new names are required")
+	}
+	cmd := c.runZmx("rename", oldName, newName)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("zmx rename %s %s: %w: %s", oldName, newName, err, stderr.String())
+	}
+	return nil
+}
+
+// CreateDetached starts a zmx session without attaching a client to it.
+// The session's shell starts in cwd (if set) and runs cmd (if non-empty).
+func (c *Client) CreateDetached(name, cwd, cmd string) error {
+	if name == "" {
+		return fmt.Errorf("zmx run: session name is required")
+	}
+
+	args := []string{"run", name}
+	if cmd != "" {
+		args = append(args, "'"+strings.ReplaceAll(cmd, "'", `'\''`)+"'")
+	}
+
+	var execCmd *exec.Cmd
+	if c.IsRemote() {
+		// kitty's --cwd doesn't apply over SSH, so cd on the remote first
+		remoteCmd := c.zmxPath() + " " + strings.Join(args, " ")
+		if cwd != "" {
+			remoteCmd = strings.TrimSuffix(CWDCommand(cwd), "exec $SHELL") + remoteCmd
+		}
+		execCmd = c.sshCmd(context.Background(), remoteCmd)
+	} else {
+		execCmd = c.runZmx(args...)
+		execCmd.Dir = cwd
+	}
+
+	var stderr bytes.Buffer
+	execCmd.Stderr = &stderr
+	if err := execCmd.Run(); err != nil {
+		return fmt.Errorf("zmx run %s: %w: %s", name, err, stderr.String())
+	}
+	return nil
+}
+
 // CWDCommand returns a shell command that cd's to the given directory.
 // Used for remote sessions where kitty's --cwd doesn't apply across SSH.
 // Uses ; instead of && so the shell starts even if the path doesn't exist.
@@ -149,18 +333,32 @@ func CWDCommand(cwd string) string {
 
 // AttachCmd returns the command to attach to a zmx session.
 // For local: ["zmx", "attach", name, ...]
-// For remote: ["kitten", "ssh", host, "-t", "zmx", "attach", name, ...]
+// For remote: ["kitten", "ssh", "-t", [-p port], host, "zmx attach name ..."]
 func (c *Client) AttachCmd(zmxName string, cmd ...string) []string {
+	return c.attachCmd(zmxName, false, cmd...)
+}
+
+// ReadOnlyAttachCmd is like AttachCmd but attaches without forwarding input.
+// Callers should check SupportsReadOnly first.
+func (c *Client) ReadOnlyAttachCmd(zmxName string, cmd ...string) []string {
+	return c.attachCmd(zmxName, true, cmd...)
+}
+
+func (c *Client) attachCmd(zmxName string, readOnly bool, cmd ...string) []string {
 	if zmxName == "" {
 		return nil
 	}
 
 	zmxPath := c.zmxPath()
+	attach := []string{"attach"}
+	if readOnly {
+		attach = append(attach, "--read-only")
+	}
 
 	if c.IsRemote() {
 		// Build remote command as a single string so SSH passes it
 		// intact to the remote shell (SSH flattens multiple args with spaces)
-		remoteCmd := zmxPath + " attach " + zmxName
+		remoteCmd := zmxPath + " " + strings.Join(attach, " ") + " " + zmxName
 		for _, cm := range cmd {
 			if cm != "" {
 				// Double-quote the command for remote shell: protects shell
@@ -172,11 +370,13 @@ func (c *Client) AttachCmd(zmxName string, cmd ...string) []string {
 				break
 			}
 		}
-		return []string{"kitten", "ssh", "-t", c.host, remoteCmd}
+		args := append([]string{"kitten", "ssh", "-t"}, c.hostCfg.SSHDestination(c.host)...)
+		return append(args, remoteCmd)
 	}
 
 	// Local: direct zmx command
-	args := []string{zmxPath, "attach", zmxName}
+	args := append([]string{zmxPath}, attach...)
+	args = append(args, zmxName)
 
 	// Add command through interactive shell (loads user's PATH)
 	for _, cm := range cmd {
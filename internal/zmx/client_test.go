@@ -1,7 +1,16 @@
 package zmx
 
 import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/cwel/kmux/internal/config"
 )
 
 func TestParseList(t *testing.T) {
@@ -35,3 +44,121 @@ func TestParseListEmpty(t *testing.T) {
 		t.Errorf("expected 0 sessions for 'no sessions found', got %d", len(sessions))
 	}
 }
+
+func TestIsNoSessions(t *testing.T) {
+	if !isNoSessions("error: no sessions found in /tmp/zmx-1000\n") {
+		t.Error("expected no-sessions output to be recognized")
+	}
+	if isNoSessions("session_name=work.0.0\tpid=1\tclients=1") {
+		t.Error("session list is not a no-sessions message")
+	}
+}
+
+func TestReadOnlyAttachCmd(t *testing.T) {
+	local := NewClient()
+	got := local.ReadOnlyAttachCmd("work.0.0")
+	want := []string{"zmx", "attach", "--read-only", "work.0.0"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("ReadOnlyAttachCmd() = %v, want %v", got, want)
+	}
+
+	remote := NewRemoteClient("devbox", nil)
+	got = remote.ReadOnlyAttachCmd("work.0.0")
+	if last := got[len(got)-1]; last != "zmx attach --read-only work.0.0" {
+		t.Errorf("remote ReadOnlyAttachCmd() command = %q", last)
+	}
+}
+
+func TestIsNotFound(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"command not found", exec.Command("sh", "-c", "exit 127").Run(), true},
+		{"other failure", exec.Command("sh", "-c", "exit 1").Run(), false},
+		{"missing binary", exec.Command("kmux-no-such-binary").Run(), true},
+	}
+
+	for _, tt := range tests {
+		if got := isNotFound(tt.err); got != tt.want {
+			t.Errorf("%s: isNotFound() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestListContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewClient().ListContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("ListContext() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestParseCapabilities(t *testing.T) {
+	help := `zmx - session persistence
+
+Usage: zmx <command> [args]
+
+Commands:
+  attach <name> [cmd...]   attach to a session (--read-only to watch)
+  run <name> [cmd...]      start a detached session
+  list                     list sessions
+  kill <name>              kill a session
+  rename <old> <new>       rename a session
+`
+	caps := parseCapabilities("zmx 0.4.1\n", help)
+
+	if caps.Version != "zmx 0.4.1" {
+		t.Errorf("Version = %q, want %q", caps.Version, "zmx 0.4.1")
+	}
+	for _, sub := range []string{"attach", "run", "list", "kill", "rename"} {
+		if !caps.Has(sub) {
+			t.Errorf("Has(%q) = false, want true", sub)
+		}
+	}
+	if caps.Has("history") || caps.Has("zmx") || caps.Has("Usage:") {
+		t.Errorf("unexpected subcommands: %v", caps.Subcommands)
+	}
+	if !caps.ReadOnly {
+		t.Error("ReadOnly = false, want true")
+	}
+
+	old := parseCapabilities("sh: zmx: command not found", "attach <name>\nlist\n")
+	if old.Version != "" || old.Has("rename") || old.ReadOnly {
+		t.Errorf("old zmx caps = %+v, want no version, rename or read-only", old)
+	}
+}
+
+func TestRemoteListDoesNotWaitForControlMaster(t *testing.T) {
+	// Stub ssh: whichever invocation would keep a shared connection alive
+	// (ControlPersist) leaves a child in the background holding its stdout
+	// and stderr, like a real persisting master does.
+	dir := t.TempDir()
+	script := `#!/bin/sh
+case "$*" in
+*"-O check"*) exit 255 ;;
+*ControlPersist*) sleep 5 & exit 0 ;;
+esac
+printf 'session_name=proj.0.0\tpid=1\tclients=0\n'
+`
+	if err := os.WriteFile(filepath.Join(dir, "ssh"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	client := NewRemoteClient("devbox", &config.HostConfig{ControlPath: filepath.Join(dir, "cm-%h")})
+	start := time.Now()
+	sessions, err := client.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(sessions) != 1 || sessions[0] != "proj.0.0" {
+		t.Errorf("List() = %v, want [proj.0.0]", sessions)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("List() took %v, waiting on the background ssh master", elapsed)
+	}
+}